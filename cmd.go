@@ -5,11 +5,12 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"html/template"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 
 	"github.com/travelaudience/rosie/pkg/dag"
 )
@@ -18,17 +19,24 @@ var (
 	_ Executor = &CmdTask{}
 )
 
+// cmdClosure produces a command ready to be started and its human-friendly description.
+type cmdClosure func(context.Context, Resulter) (*exec.Cmd, string)
+
 // CmdTask is a type of task that executes locally available programs.
 type CmdTask struct {
 	*task
-	closure func(context.Context, Resulter) *exec.Cmd
+	closure cmdClosure
 	wraps   *CmdTask
+
+	dir      string
+	exitCode int
 }
 
 // Cmd instantiate new CmdTask object.
 // It requires at least one command to be passed, otherwise, it panics.
 // Each and every element in the slice is threatened as a template.
-// It understands annotations surrounded by `[[...]]` for example [[.Result.Value]].
+// Templates are executed by text/template, like the ones of RenderTemplate, missing parameters cause a panic with InitError.
+// It understands annotations surrounded by `[[...]]` for example [[.Result.Value]] or [[.Params.version]] (see WithParams).
 func Cmd(name string, commands ...string) *CmdTask {
 	if len(commands) == 0 {
 		panic(&InitError{
@@ -39,39 +47,33 @@ func Cmd(name string, commands ...string) *CmdTask {
 	t := &CmdTask{
 		task: &task{name: name},
 	}
-	t.closure = func(ctx context.Context, res Resulter) *exec.Cmd {
-		buf := bytes.NewBuffer(nil)
+	t.closure = func(ctx context.Context, res Resulter) (*exec.Cmd, string) {
+		rendered := make([]string, len(commands))
 		for i, command := range commands {
-			tmpl, err := template.New(fmt.Sprintf("%s-%d", name, i)).
-				Delims("[[", "]]").
-				Parse(command)
+			tmpl, err := parse(fmt.Sprintf("%s-%d", name, i), command)
 			if err != nil {
 				panic(&InitError{
 					msg: fmt.Sprintf("command template (%s) initialization failure", command),
 					err: err,
 				})
 			}
-			if err := tmpl.Execute(buf, struct {
-				Result interface{}
-			}{
-				Result: res.Result(),
-			}); err != nil {
+			rendered[i], err = execute(tmpl, templateData{
+				Result: templateResult{res.Result()},
+				Params: ParamsFrom(ctx),
+			})
+			if err != nil {
 				panic(&InitError{
 					msg: "command template execution failure",
 					err: err,
 				})
 			}
-			commands[i] = buf.String()
-			buf.Reset()
 		}
 
 		/* #nosec */
-		cmd := exec.CommandContext(ctx, commands[0], commands[1:]...)
+		cmd := exec.CommandContext(ctx, rendered[0], rendered[1:]...)
 		cmd.Env = os.Environ()
 
-		t.description = strings.Join(commands, " ")
-
-		return cmd
+		return cmd, strings.Join(rendered, " ")
 	}
 	t.setAnchor(&dag.Node{}, t)
 	return t
@@ -83,12 +85,11 @@ func Dir(wrapped *CmdTask, dir string) *CmdTask {
 		task:  &task{name: fmt.Sprintf("dir(%s)", wrapped.name)},
 		wraps: wrapped,
 	}
-	t.closure = func(ctx context.Context, res Resulter) *exec.Cmd {
-		cmd := wrapped.closure(ctx, res)
+	t.closure = func(ctx context.Context, res Resulter) (*exec.Cmd, string) {
+		cmd, desc := wrapped.closure(ctx, res)
 		cmd.Dir = dir
-		t.description = wrapped.description + " [" + dir + "]"
 
-		return cmd
+		return cmd, desc + " [" + dir + "]"
 	}
 	t.setAnchor(&dag.Node{}, t)
 	return t
@@ -101,61 +102,140 @@ func Env(wrapped *CmdTask, env ...string) *CmdTask {
 
 		wraps: wrapped,
 	}
-	t.closure = func(ctx context.Context, res Resulter) *exec.Cmd {
-		cmd := wrapped.closure(ctx, res)
+	t.closure = func(ctx context.Context, res Resulter) (*exec.Cmd, string) {
+		cmd, desc := wrapped.closure(ctx, res)
 		cmd.Env = append(cmd.Env, env...)
-		t.description = wrapped.description
 
-		return cmd
+		return cmd, desc
 	}
 	t.setAnchor(&dag.Node{}, t)
 	return t
 }
 
 // MakeDir creates a directory.
+//
+// Deprecated: use Mkdir instead, it does not depend on the mkdir program.
 func MakeDir(dir string) *CmdTask {
 	return Cmd("mkdir", "mkdir", "-p", dir)
 }
 
 // RemoveDir removes a directory and all files/directories inside.
+//
+// Deprecated: use Remove instead, it does not depend on the rm program.
 func RemoveDir(dir string) *CmdTask {
 	return Cmd("rmdir", "rm", "-rf", dir)
 }
 
+// Dir returns the working directory of the last execution, empty means the directory of the calling process.
+func (t *CmdTask) Dir() string {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.dir
+}
+
+// ExitCode returns the exit code of the last execution, or -1 if the program did not exit (yet).
+func (t *CmdTask) ExitCode() int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.started.IsZero() {
+		return -1
+	}
+	return t.exitCode
+}
+
+// Render returns the command as it would be executed if the previous task produced the given value.
+// It is meant for previews and tests, template failures are returned as *InitError.
+func (t *CmdTask) Render(value interface{}) (string, error) {
+	return t.RenderContext(context.Background(), value)
+}
+
+// RenderContext is like Render, but the command is rendered with the context, e.g. one that carries parameters of the workflow.
+func (t *CmdTask) RenderContext(ctx context.Context, value interface{}) (desc string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			ie, ok := r.(*InitError)
+			if !ok {
+				panic(r)
+			}
+			err = ie
+		}
+	}()
+
+	_, desc = t.closure(ctx, staticResulter{res: Result{value: value}})
+	return desc, nil
+}
+
+// clone implements cloner interface.
+func (t *CmdTask) clone(anchor *dag.Node, _ map[*dag.Node]*dag.Node) *task {
+	c := &CmdTask{
+		task:    t.task.copy(),
+		closure: t.closure,
+		wraps:   t.wraps,
+	}
+	c.setAnchor(anchor, c)
+
+	return c.task
+}
+
 // Exec implements Executor interface.
 func (t *CmdTask) Exec(ctx context.Context) (<-chan Piece, error) {
+	t.start()
+
 	previousResulter := t.gatherParentResults()
 	previousResult := previousResulter.Result()
 
-	cmd := t.closure(ctx, previousResulter)
+	cmd, desc := t.closure(ctx, previousResulter)
+	t.setDescription(desc)
+	t.lock.Lock()
+	t.dir = cmd.Dir
+	t.exitCode = -1
+	t.lock.Unlock()
 	out := make(chan Piece)
 
 	stdres := bytes.NewBuffer(nil)
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, err
-	}
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return nil, err
-	}
+	stdoutR, stdoutW := io.Pipe()
+	stderrR, stderrW := io.Pipe()
+	cmd.Stdout = stdoutW
+	cmd.Stderr = stderrW
 
-	if err := cmd.Start(); err != nil {
+	proc, err := CommanderFrom(ctx).Start(ctx, cmd)
+	if err != nil {
+		// Nothing is going to read or write the pipes.
+		_ = stdoutW.Close()
+		_ = stderrW.Close()
+		_ = stdoutR.Close()
+		_ = stderrR.Close()
 		t.setErr(err)
 		return nil, err
 	}
 
 	go func() {
-		sc := bufio.NewScanner(io.TeeReader(stdout, stdres))
-		for sc.Scan() {
-			out <- Piece{Text: sc.Text()}
-		}
-		sc = bufio.NewScanner(stderr)
-		for sc.Scan() {
-			out <- Piece{Text: sc.Text()}
+		var wg sync.WaitGroup
+		forward := func(r io.Reader) {
+			defer wg.Done()
+
+			sc := bufio.NewScanner(r)
+			for sc.Scan() {
+				out <- Piece{Text: sc.Text()}
+			}
+			// Anything left (e.g. a line too long to scan) is discarded, so the command is never blocked.
+			_, _ = io.Copy(ioutil.Discard, r)
 		}
+		wg.Add(2)
+		go forward(io.TeeReader(stdoutR, stdres))
+		go forward(stderrR)
+
+		code, err := proc.Wait()
+		_ = stdoutW.Close()
+		_ = stderrW.Close()
+		wg.Wait()
 
-		if err := cmd.Wait(); err != nil {
+		t.lock.Lock()
+		t.exitCode = code
+		t.lock.Unlock()
+		if err != nil {
 			out <- Piece{Err: err}
 			t.setErr(err)
 			close(out)
@@ -163,7 +243,7 @@ func (t *CmdTask) Exec(ctx context.Context) (<-chan Piece, error) {
 		}
 
 		var res []string
-		sc = bufio.NewScanner(stdres)
+		sc := bufio.NewScanner(stdres)
 		for sc.Scan() {
 			res = append(res, sc.Text())
 		}
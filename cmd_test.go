@@ -60,6 +60,62 @@ func TestCmd_optimistic(t *testing.T) {
 	testrunner.Run(t, g, noError)
 }
 
+func TestCmd_dirAndEnv(t *testing.T) {
+	fake := &testrunner.FakeCommander{}
+	fake.On("ls").Stdout("runner.go")
+	fake.On("printenv", "TEST_VAR").Stdout("TACTL_OK")
+
+	g := rosie.Group("test-group")
+	g.Beginning().
+		Then(rosie.Dir(rosie.Cmd("list", "ls"), "pkg/runner/testrunner")).
+		Then(rosie.Env(rosie.Cmd("env", "printenv", "TEST_VAR"), "TEST_VAR=TACTL_OK"))
+
+	rec := testrunner.ExecuteContext(rosie.WithCommander(context.Background(), fake), t, g)
+	if rec.Err != nil {
+		t.Fatal(rec.Err)
+	}
+	testrunner.AssertOrder(t, rec, "dir(list)", "env(env)")
+
+	if calls := fake.Called("ls"); len(calls) != 1 || calls[0].Dir != "pkg/runner/testrunner" {
+		t.Errorf("ls should be called in the directory, calls: %+v", calls)
+	}
+	calls := fake.Called("printenv", "TEST_VAR")
+	if len(calls) != 1 {
+		t.Fatalf("printenv should be called once, calls: %+v", calls)
+	}
+	var found bool
+	for _, kv := range calls[0].Env {
+		found = found || kv == "TEST_VAR=TACTL_OK"
+	}
+	if !found {
+		t.Errorf("TEST_VAR is missing in the environment: %v", calls[0].Env)
+	}
+}
+
+func TestCmd_fakeCommander(t *testing.T) {
+	fake := &testrunner.FakeCommander{}
+	fake.On("ls", "...").Stdout("cmd.go\nfn.go")
+	fake.On("grep", "fn.go").ExitCode(1)
+
+	list := rosie.Cmd("list", "ls", "-lha")
+	grep := rosie.Cmd("grep", "grep", "[[index .Result.Value 1]]")
+	g := rosie.Group("test-group")
+	g.Beginning().
+		Then(list).
+		Then(grep)
+
+	rec := testrunner.ExecuteContext(rosie.WithCommander(context.Background(), fake), t, g)
+	if rec.Err == nil {
+		t.Fatal("error expected")
+	}
+	testrunner.AssertResult(t, rec, "list", []string{"cmd.go", "fn.go"})
+	testrunner.AssertFailed(t, rec, "grep")
+	fake.AssertCalled(t, "grep", "fn.go")
+	if list.ExitCode() != 0 || grep.ExitCode() != 1 {
+		t.Errorf("unexpected exit codes: %d, %d", list.ExitCode(), grep.ExitCode())
+	}
+}
+
 func TestCmd_pessimisticLackOfCommands(t *testing.T) {
 	defer assertPanicInitError(t)
 
@@ -90,6 +146,16 @@ func TestCmd_pessimisticMissingTemplateArguments(t *testing.T) {
 	testrunner.Run(t, g, noError)
 }
 
+func TestCmd_pessimisticMissingParam(t *testing.T) {
+	defer assertPanicInitError(t)
+
+	g := rosie.Group("test-group")
+	g.Beginning().
+		Then(rosie.Cmd("echo", "echo", "[[.Params.base]]"))
+
+	testrunner.Run(t, g, noError)
+}
+
 func assertPanicInitError(t *testing.T) {
 	if err := recover(); err != nil {
 		if _, ok := err.(*rosie.InitError); ok {
@@ -0,0 +1,61 @@
+package rosie
+
+import (
+	"context"
+	"os/exec"
+)
+
+// Commander starts programs on behalf of CmdTask.
+// The default one starts them as processes of the operating system, tests can inject a fake one using WithCommander.
+type Commander interface {
+	// Start starts the command described by cmd (Path, Args, Dir and Env).
+	// The output has to be written to cmd.Stdout and cmd.Stderr.
+	Start(ctx context.Context, cmd *exec.Cmd) (Process, error)
+}
+
+// Process is a started command.
+type Process interface {
+	// Wait waits for the command to exit and returns its exit code, or -1 if it did not exit.
+	// It returns an error if the command failed, including non-zero exit codes.
+	Wait() (int, error)
+}
+
+type commanderKey struct{}
+
+// WithCommander returns a copy of the context that makes CmdTask use the given commander.
+func WithCommander(ctx context.Context, c Commander) context.Context {
+	return context.WithValue(ctx, commanderKey{}, c)
+}
+
+// CommanderFrom returns the commander carried by the context, or the one that starts processes of the operating system.
+// It lets tasks other than CmdTask start programs the same way, so they can be tested with a fake one too.
+func CommanderFrom(ctx context.Context) Commander {
+	if c, ok := ctx.Value(commanderKey{}).(Commander); ok {
+		return c
+	}
+	return osCommander{}
+}
+
+// osCommander starts commands as processes of the operating system.
+type osCommander struct{}
+
+// Start implements Commander interface.
+func (osCommander) Start(_ context.Context, cmd *exec.Cmd) (Process, error) {
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return osProcess{cmd: cmd}, nil
+}
+
+type osProcess struct {
+	cmd *exec.Cmd
+}
+
+// Wait implements Process interface.
+func (p osProcess) Wait() (int, error) {
+	err := p.cmd.Wait()
+	if p.cmd.ProcessState == nil {
+		return -1, err
+	}
+	return p.cmd.ProcessState.ExitCode(), err
+}
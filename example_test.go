@@ -45,5 +45,5 @@ func Example() {
 
 	fmt.Println(count)
 
-	// Output: 6
+	// Output: 15
 }
@@ -3,20 +3,14 @@ package rosie
 import (
 	"bufio"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
-	"path"
 	"reflect"
 	"strconv"
 
-	"gopkg.in/yaml.v2"
-
 	"github.com/travelaudience/rosie/pkg/dag"
-	"github.com/travelaudience/rosie/pkg/vars"
 )
 
 var (
@@ -40,6 +34,13 @@ func Fn(name string, closure FnClosure) *FnTask {
 	return t
 }
 
+// DescribedFn is like Fn, runners show the description the same way as commands of CmdTask.
+func DescribedFn(name, desc string, closure FnClosure) *FnTask {
+	t := Fn(name, closure)
+	t.setDescription(desc)
+	return t
+}
+
 type transformKey struct{}
 
 // Nothing can be used in combination with Transform to exclude given object from a collection.
@@ -131,46 +132,21 @@ func Without(without interface{}) *FnTask {
 	}
 }
 
-// UnmarshalFile ...
-func UnmarshalFile(into interface{}) *FnTask {
-	return Fn("unmarshal-file", func(_ context.Context, w io.Writer, res Resulter) (interface{}, error) {
-		filePath, ok := res.Result().Value().(string)
-		if !ok {
-			return nil, TypeError(reflect.String, res.Result().Value())
-		}
-
-		/* #nosec */
-		buf, err := ioutil.ReadFile(filePath)
-		if err != nil {
-			return nil, err
-		}
-
-		if _, err = fmt.Fprintf(w, "file %s read %dB\n", filePath, len(buf)); err != nil {
-			return nil, err
-		}
-
-		ext := path.Ext(filePath)
-		switch ext {
-		case vars.ExtYAML:
-			if err = yaml.Unmarshal(buf, into); err != nil {
-				return nil, err
-			}
-		case vars.ExtJSON:
-			if err = json.Unmarshal(buf, into); err != nil {
-				return nil, err
-			}
-		}
-
-		if _, err = fmt.Fprintf(w, "file content unmarshaled using %s unmarshaller\n", ext); err != nil {
-			return nil, err
-		}
+// clone implements cloner interface.
+func (t *FnTask) clone(anchor *dag.Node, _ map[*dag.Node]*dag.Node) *task {
+	c := &FnTask{
+		task:    t.task.copy(),
+		closure: t.closure,
+	}
+	c.setAnchor(anchor, c)
 
-		return into, nil
-	})
+	return c.task
 }
 
 // Exec implements Executor interface.
 func (t *FnTask) Exec(ctx context.Context) (<-chan Piece, error) {
+	t.start()
+
 	if t.previousResulter == nil {
 		t.previousResulter = t.gatherParentResults()
 	}
@@ -9,12 +9,20 @@ import (
 	"github.com/travelaudience/rosie/pkg/dag"
 )
 
+var (
+	_ Executor = &forEachTask{}
+	_ cloner   = &forEachTask{}
+)
+
 // ForEach allows executing logic for each piece of the result produced by step before.
 // It will panic if received data is not a slice or a map.
 // Finally once each end every piece of work is done all slice are gathered by the closing task (group end) in form of a slice.
 func ForEach(name string, fn func(key string) Attacher) *GroupTask {
-	beginning := &FnTask{
-		task: newHiddenTask(fmt.Sprintf("for-each(%s)", name)),
+	beginning := &forEachTask{
+		FnTask: &FnTask{
+			task: newHiddenTask(fmt.Sprintf("for-each(%s)", name)),
+		},
+		fn: fn,
 	}
 	end := &FnTask{
 		task: newHiddenTask(fmt.Sprintf("for-each(%s)-gather-slice", name)),
@@ -24,41 +32,8 @@ func ForEach(name string, fn func(key string) Attacher) *GroupTask {
 	beginning.setAnchor(anchorBeginning, beginning)
 	end.setAnchor(anchorEnd, end)
 
-	beginning.closure = func(_ context.Context, _ io.Writer, res Resulter) (interface{}, error) {
-		if fn == nil {
-			return nil, nil
-		}
-
-		add := func(key string, res Result) {
-			staticInputTask := newHiddenTask(fmt.Sprintf("%s-static-input", key))
-			staticInputTask.setResult(res)
-			staticInputTask.anchor.Between(anchorBeginning, anchorEnd)
-
-			fn(key).Node().Between(staticInputTask.anchor, anchorEnd)
-		}
-		val := reflect.ValueOf(res.Result().value)
-		switch val.Type().Kind() {
-		case reflect.Slice:
-			if val.IsNil() {
-				return nil, nil
-			}
-			for i := 0; i < val.Len(); i++ {
-				add(fmt.Sprintf("%d/%d", i+1, val.Len()), Result{
-					value: val.Index(i).Interface(),
-				})
-			}
-		case reflect.Map:
-			for _, key := range val.MapKeys() {
-				add(fmt.Sprintf("%v", key.Interface()), Result{
-					key:   fmt.Sprintf("%v", key.Interface()),
-					value: val.MapIndex(key).Interface(),
-				})
-			}
-		default:
-			return nil, fmt.Errorf("rosie: for-each: unexpected type: %T", res.Result())
-		}
-		return nil, nil
-	}
+	beginning.closure = beginning.expand
+	beginning.end = anchorEnd
 
 	end.closure = func(_ context.Context, _ io.Writer, res Resulter) (interface{}, error) {
 		return res.Result().Value(), nil
@@ -69,3 +44,62 @@ func ForEach(name string, fn func(key string) Attacher) *GroupTask {
 		end:       end.task,
 	}
 }
+
+// forEachTask is the beginning of a ForEach group.
+// It extends the group at run time with a branch for each piece of the result produced by step before.
+type forEachTask struct {
+	*FnTask
+	fn  func(key string) Attacher
+	end *dag.Node
+}
+
+func (t *forEachTask) expand(_ context.Context, _ io.Writer, res Resulter) (interface{}, error) {
+	if t.fn == nil {
+		return nil, nil
+	}
+
+	add := func(key string, res Result) {
+		staticInputTask := newHiddenTask(fmt.Sprintf("%s-static-input", key))
+		staticInputTask.setResult(res)
+		staticInputTask.anchor.Between(t.anchor, t.end)
+
+		t.fn(key).Node().Between(staticInputTask.anchor, t.end)
+	}
+	val := reflect.ValueOf(res.Result().value)
+	switch val.Type().Kind() {
+	case reflect.Slice:
+		if val.IsNil() {
+			return nil, nil
+		}
+		for i := 0; i < val.Len(); i++ {
+			add(fmt.Sprintf("%d/%d", i+1, val.Len()), Result{
+				value: val.Index(i).Interface(),
+			})
+		}
+	case reflect.Map:
+		for _, key := range val.MapKeys() {
+			add(fmt.Sprintf("%v", key.Interface()), Result{
+				key:   fmt.Sprintf("%v", key.Interface()),
+				value: val.MapIndex(key).Interface(),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("rosie: for-each: unexpected type: %T", res.Result())
+	}
+	return nil, nil
+}
+
+// clone implements cloner interface.
+func (t *forEachTask) clone(anchor *dag.Node, nodes map[*dag.Node]*dag.Node) *task {
+	c := &forEachTask{
+		FnTask: &FnTask{
+			task: t.task.copy(),
+		},
+		fn:  t.fn,
+		end: nodes[t.end],
+	}
+	c.closure = c.expand
+	c.setAnchor(anchor, c)
+
+	return c.task
+}
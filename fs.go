@@ -0,0 +1,359 @@
+package rosie
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"syscall"
+)
+
+// Mkdir creates a directory, along with any missing parents.
+// It produces the path of the directory.
+func Mkdir(dir string) *FnTask {
+	return DescribedFn("mkdir", "mkdir "+dir, func(_ context.Context, w io.Writer, _ Resulter) (interface{}, error) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+		if _, err := fmt.Fprintf(w, "created %s\n", dir); err != nil {
+			return nil, err
+		}
+		return dir, nil
+	})
+}
+
+// Remove removes files and directories, along with everything they contain.
+// Paths can be glob patterns, paths that do not exist are ignored.
+// It produces the list of removed paths.
+func Remove(paths ...string) *FnTask {
+	return DescribedFn("remove", "remove "+strings.Join(paths, " "), func(_ context.Context, w io.Writer, _ Resulter) (interface{}, error) {
+		removed := []string{}
+		for _, p := range paths {
+			matches, err := expand(p)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range matches {
+				if _, err := os.Lstat(m); os.IsNotExist(err) {
+					continue
+				}
+				if err := os.RemoveAll(m); err != nil {
+					return nil, err
+				}
+				if _, err := fmt.Fprintf(w, "removed %s\n", m); err != nil {
+					return nil, err
+				}
+				removed = append(removed, m)
+			}
+		}
+		return removed, nil
+	})
+}
+
+// Copy copies files and directories (recursively), preserving permissions and symbolic links.
+// The source can be a glob pattern. If it matches more than one path, dst ends with a separator or is an existing directory,
+// sources are copied into dst, otherwise dst is the path of the copy.
+// It produces the list of created paths.
+func Copy(src, dst string) *FnTask {
+	return DescribedFn("copy", fmt.Sprintf("copy %s -> %s", src, dst), func(_ context.Context, w io.Writer, _ Resulter) (interface{}, error) {
+		sources, err := expand(src)
+		if err != nil {
+			return nil, err
+		}
+		targets, err := destinations(sources, src, dst)
+		if err != nil {
+			return nil, err
+		}
+		for i, s := range sources {
+			if err := copyPath(s, targets[i]); err != nil {
+				return nil, err
+			}
+			if _, err := fmt.Fprintf(w, "copied %s -> %s\n", s, targets[i]); err != nil {
+				return nil, err
+			}
+		}
+		return targets, nil
+	})
+}
+
+// Move moves (renames) files and directories, falling back to copy and remove across devices.
+// The source and destination follow the rules of Copy.
+// It produces the list of new paths.
+func Move(src, dst string) *FnTask {
+	return DescribedFn("move", fmt.Sprintf("move %s -> %s", src, dst), func(_ context.Context, w io.Writer, _ Resulter) (interface{}, error) {
+		sources, err := expand(src)
+		if err != nil {
+			return nil, err
+		}
+		targets, err := destinations(sources, src, dst)
+		if err != nil {
+			return nil, err
+		}
+		for i, s := range sources {
+			if err := movePath(s, targets[i]); err != nil {
+				return nil, err
+			}
+			if _, err := fmt.Fprintf(w, "moved %s -> %s\n", s, targets[i]); err != nil {
+				return nil, err
+			}
+		}
+		return targets, nil
+	})
+}
+
+// Symlink creates a symbolic link pointing to the target.
+// It produces the path of the link.
+func Symlink(target, link string) *FnTask {
+	return DescribedFn("symlink", fmt.Sprintf("symlink %s -> %s", link, target), func(_ context.Context, w io.Writer, _ Resulter) (interface{}, error) {
+		if err := os.Symlink(target, link); err != nil {
+			return nil, err
+		}
+		if _, err := fmt.Fprintf(w, "linked %s -> %s\n", link, target); err != nil {
+			return nil, err
+		}
+		return link, nil
+	})
+}
+
+// WriteFile writes the result of the previous task into a file, creating missing parent directories.
+// The result can be a string, []byte, []string (written line by line) or fmt.Stringer.
+// The file is replaced atomically. It produces the path of the file.
+func WriteFile(path string, perm os.FileMode) *FnTask {
+	return DescribedFn("write-file", fmt.Sprintf("write %s (%s)", path, perm), func(_ context.Context, w io.Writer, res Resulter) (interface{}, error) {
+		var buf []byte
+		switch v := res.Result().Value().(type) {
+		case string:
+			buf = []byte(v)
+		case []byte:
+			buf = v
+		case []string:
+			for _, line := range v {
+				buf = append(buf, line...)
+				buf = append(buf, '\n')
+			}
+		case fmt.Stringer:
+			buf = []byte(v.String())
+		default:
+			return nil, TypeError(reflect.String, v)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+		if err := writeFileAtomic(path, buf, perm); err != nil {
+			return nil, err
+		}
+		if _, err := fmt.Fprintf(w, "file %s written %dB\n", path, len(buf)); err != nil {
+			return nil, err
+		}
+		return path, nil
+	})
+}
+
+// Chmod changes the mode of files and directories, paths can be glob patterns.
+// It produces the list of changed paths.
+func Chmod(mode os.FileMode, paths ...string) *FnTask {
+	return DescribedFn("chmod", fmt.Sprintf("chmod %#o %s", mode.Perm(), strings.Join(paths, " ")), func(_ context.Context, w io.Writer, _ Resulter) (interface{}, error) {
+		changed := []string{}
+		for _, p := range paths {
+			matches, err := expand(p)
+			if err != nil {
+				return nil, err
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %s", p)
+			}
+			for _, m := range matches {
+				if err := os.Chmod(m, mode); err != nil {
+					return nil, err
+				}
+				if _, err := fmt.Fprintf(w, "changed mode of %s to %s\n", m, mode.Perm()); err != nil {
+					return nil, err
+				}
+				changed = append(changed, m)
+			}
+		}
+		return changed, nil
+	})
+}
+
+// Glob lists paths matching the pattern (see filepath.Match for the syntax) in lexical order.
+// It produces a []string, even if nothing matches, so it can feed ForEach.
+func Glob(pattern string) *FnTask {
+	return DescribedFn("glob", "glob "+pattern, func(_ context.Context, w io.Writer, _ Resulter) (interface{}, error) {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fmt.Fprintf(w, "%d paths match %s\n", len(matches), pattern); err != nil {
+			return nil, err
+		}
+		if matches == nil {
+			matches = []string{}
+		}
+		return matches, nil
+	})
+}
+
+// expand returns paths matching the pattern, a path without any meta characters is returned as is, even if it does not exist.
+func expand(pattern string) ([]string, error) {
+	if !hasMeta(pattern) {
+		return []string{pattern}, nil
+	}
+	return filepath.Glob(pattern)
+}
+
+// hasMeta reports whether the path contains any of the meta characters of filepath.Match.
+// A backslash escapes on Unix only, on Windows it is the path separator.
+func hasMeta(path string) bool {
+	magic := `*?[`
+	if runtime.GOOS != "windows" {
+		magic = `*?[\`
+	}
+	return strings.ContainsAny(path, magic)
+}
+
+// destinations computes where each of the sources is copied or moved to.
+func destinations(sources []string, src, dst string) ([]string, error) {
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no files match %s", src)
+	}
+
+	into := len(sources) > 1 || strings.HasSuffix(dst, "/") || strings.HasSuffix(dst, string(filepath.Separator))
+	if fi, err := os.Stat(dst); err == nil && fi.IsDir() {
+		into = true
+	}
+
+	targets := make([]string, 0, len(sources))
+	for _, s := range sources {
+		t := dst
+		if into {
+			t = filepath.Join(dst, filepath.Base(s))
+		}
+		inside, err := within(t, s)
+		if err != nil {
+			return nil, err
+		}
+		if inside {
+			return nil, fmt.Errorf("cannot copy or move %s into itself (%s)", s, t)
+		}
+		targets = append(targets, t)
+	}
+
+	if into {
+		if err := os.MkdirAll(dst, 0755); err != nil {
+			return nil, err
+		}
+	}
+	return targets, nil
+}
+
+// within reports whether the path is the dir itself or lies inside of it.
+func within(path, dir string) (bool, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false, nil
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+}
+
+func copyPath(src, dst string) error {
+	fi, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case fi.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	case fi.IsDir():
+		if err := os.MkdirAll(dst, fi.Mode().Perm()); err != nil {
+			return err
+		}
+		entries, err := ioutil.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := copyPath(filepath.Join(src, e.Name()), filepath.Join(dst, e.Name())); err != nil {
+				return err
+			}
+		}
+		return os.Chmod(dst, fi.Mode().Perm())
+	default:
+		return copyFile(src, dst, fi.Mode().Perm())
+	}
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	/* #nosec */
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	/* #nosec */
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(dst, perm)
+}
+
+func movePath(src, dst string) error {
+	err := os.Rename(src, dst)
+	if le, ok := err.(*os.LinkError); ok && le.Err == syscall.EXDEV {
+		if err := copyPath(src, dst); err != nil {
+			return err
+		}
+		return os.RemoveAll(src)
+	}
+	return err
+}
+
+// writeFileAtomic writes the data into a temporary file next to the destination and renames it,
+// so readers never observe a partially written file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+"-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,138 @@
+package rosie_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/runner/testrunner"
+)
+
+func TestFileSystem(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rosie-fs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	in := func(p ...string) string {
+		return filepath.Join(append([]string{dir}, p...)...)
+	}
+
+	g := rosie.Group("fs")
+	g.Beginning().
+		Then(rosie.Mkdir(in("src", "nested"))).
+		Then(rosie.Fn("content", func(_ context.Context, _ io.Writer, _ rosie.Resulter) (interface{}, error) {
+			return []string{"a", "b"}, nil
+		})).
+		Then(rosie.WriteFile(in("src", "nested", "a.txt"), 0600)).
+		Then(rosie.Symlink("nested/a.txt", in("src", "link"))).
+		Then(rosie.Copy(in("src"), in("copy"))).
+		Then(rosie.Move(in("copy", "nested", "*.txt"), in("moved")+"/")).
+		Then(rosie.Chmod(0640, in("moved", "*"))).
+		Then(rosie.Remove(in("src", "link"), in("missing"))).
+		Then(rosie.Glob(in("*")))
+
+	rec := testrunner.Execute(t, g)
+	if rec.Err != nil {
+		t.Fatal(rec.Err)
+	}
+
+	testrunner.AssertOrder(t, rec, "mkdir", "write-file", "symlink", "copy", "move", "chmod", "remove", "glob")
+	testrunner.AssertResult(t, rec, "copy", []string{in("copy")})
+	testrunner.AssertResult(t, rec, "move", []string{in("moved", "a.txt")})
+	testrunner.AssertResult(t, rec, "remove", []string{in("src", "link")})
+	testrunner.AssertResult(t, rec, "glob", []string{in("copy"), in("moved"), in("src")})
+	testrunner.AssertOutputContains(t, rec, "write-file", "written 4B")
+
+	if desc := rec.Task("copy").Description; desc != "copy "+in("src")+" -> "+in("copy") {
+		t.Errorf("unexpected description: %s", desc)
+	}
+
+	buf, err := ioutil.ReadFile(in("moved", "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "a\nb\n" {
+		t.Errorf("unexpected content: %q", buf)
+	}
+	fi, err := os.Stat(in("moved", "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0640 {
+		t.Errorf("unexpected mode: %s", fi.Mode())
+	}
+	if target, err := os.Readlink(in("copy", "link")); err != nil || target != "nested/a.txt" {
+		t.Errorf("symbolic link not copied: %s, %v", target, err)
+	}
+	if _, err := os.Stat(in("copy", "nested", "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("file should be moved: %v", err)
+	}
+}
+
+func TestFileSystem_pessimistic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rosie-fs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	g := rosie.Group("fs")
+	g.Beginning().
+		Then(rosie.Copy(filepath.Join(dir, "*.go"), filepath.Join(dir, "dst"))).
+		Then(rosie.Glob(filepath.Join(dir, "*")))
+
+	rec := testrunner.Execute(t, g)
+	if rec.Err == nil {
+		t.Fatal("error expected")
+	}
+	testrunner.AssertFailed(t, rec, "copy")
+	testrunner.AssertSkipped(t, rec, "glob")
+
+	g = rosie.Group("fs")
+	g.Beginning().
+		Then(rosie.Fn("content", func(_ context.Context, _ io.Writer, _ rosie.Resulter) (interface{}, error) {
+			return 42, nil
+		})).
+		Then(rosie.WriteFile(filepath.Join(dir, "out"), 0644))
+
+	rec = testrunner.Execute(t, g)
+	if rec.Err == nil {
+		t.Fatal("error expected")
+	}
+	testrunner.AssertFailed(t, rec, "write-file")
+}
+
+func TestCopy_intoItself(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rosie-fs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	src := filepath.Join(dir, "src")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dst := range []string{src, filepath.Join(src, "sub"), filepath.Join(src, "sub", "copy")} {
+		for _, tsk := range []*rosie.FnTask{rosie.Copy(src, dst), rosie.Move(src, dst)} {
+			g := rosie.Group("fs")
+			g.Beginning().Then(tsk)
+
+			rec := testrunner.Execute(t, g)
+			if rec.Err == nil || !strings.Contains(rec.Err.Error(), "into itself") {
+				t.Errorf("%s %s -> %s: unexpected error: %v", tsk.Name(), src, dst, rec.Err)
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(src, "sub", "copy")); !os.IsNotExist(err) {
+		t.Errorf("nothing should be created: %v", err)
+	}
+}
@@ -2,4 +2,7 @@ module github.com/travelaudience/rosie
 
 go 1.12
 
-require gopkg.in/yaml.v2 v2.2.2
+require (
+	github.com/BurntSushi/toml v0.3.1
+	gopkg.in/yaml.v2 v2.2.2
+)
@@ -56,6 +56,48 @@ func (g *GroupTask) Then(next Attacher) Attacher {
 	return next
 }
 
+// Clone returns a deep copy of the group, including all the tasks it consists of.
+// The copy is not attached to any graph, so the same sub-workflow can be defined once and attached multiple times.
+// Closures are shared between the original and the copy, hence they should not hold any state.
+// It panics if the group's graph is broken.
+func (g *GroupTask) Clone() *GroupTask {
+	nodes, err := dag.Clone(g.beginning.anchor)
+	if err != nil {
+		panic(&InitError{
+			msg: fmt.Sprintf("group (%s) cannot be cloned", g.name),
+			err: err,
+		})
+	}
+
+	var beginning, end *task
+	for original, node := range nodes {
+		c, ok := original.Data.(cloner)
+		if !ok {
+			continue
+		}
+		cloned := c.clone(node, nodes)
+		switch original {
+		case g.beginning.anchor:
+			beginning = cloned
+		case g.end.anchor:
+			end = cloned
+		}
+	}
+
+	return &GroupTask{
+		name:      g.name,
+		beginning: beginning,
+		end:       end,
+	}
+}
+
+// Validate checks if the group's graph is well-formed before it is executed.
+// It returns an error that lists all problems found along with paths of the affected tasks,
+// e.g. cycles, unreachable tasks, tasks attached to multiple graphs, groups reused without being cloned or missing end nodes.
+func (g *GroupTask) Validate() error {
+	return dag.Validate(g.beginning.anchor)
+}
+
 // Iter ...
 func (g *GroupTask) Iter() (*Iterator, error) {
 	return newIterator(g.beginning.anchor)
@@ -0,0 +1,118 @@
+package rosie_test
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/runner/testrunner"
+)
+
+func TestGroupTask_Clone(t *testing.T) {
+	var (
+		got  []string
+		lock sync.Mutex
+	)
+	record := func(_ context.Context, _ io.Writer, res rosie.Resulter) (interface{}, error) {
+		lock.Lock()
+		got = append(got, res.Result().Value().(string))
+		lock.Unlock()
+		return res.Result().Value(), nil
+	}
+	stub := func(value string) *rosie.FnTask {
+		return rosie.Fn("stub", func(_ context.Context, _ io.Writer, _ rosie.Resulter) (interface{}, error) {
+			return value, nil
+		})
+	}
+
+	lint := rosie.Group("lint")
+	lint.Beginning().
+		Then(rosie.Cmd("echo", "echo", "[[ .Result.Value ]]")).
+		Then(rosie.Fn("join", rosie.StringSliceClosure(func(_ context.Context, _ io.Writer, res []string) (interface{}, error) {
+			return strings.Join(res, ""), nil
+		}))).
+		Then(rosie.Fn("record", record))
+
+	first := rosie.Group("first")
+	first.Beginning().
+		Then(stub("a")).
+		Then(lint.Clone())
+
+	second := rosie.Group("second")
+	second.Beginning().
+		Then(stub("b")).
+		Then(lint.Clone())
+
+	testrunner.Run(t, first, noError)
+	testrunner.Run(t, second, noError)
+
+	if exp := []string{"a", "b"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("wrong records, expected %v but got %v", exp, got)
+	}
+}
+
+func TestGroupTask_Clone_forEach(t *testing.T) {
+	var (
+		got  []string
+		lock sync.Mutex
+	)
+	each := rosie.Group("each")
+	each.Beginning().
+		Then(rosie.ForEach("print", func(key string) rosie.Attacher {
+			return rosie.Fn("record", rosie.StringClosure(func(_ context.Context, _ io.Writer, res string) (interface{}, error) {
+				lock.Lock()
+				got = append(got, res)
+				lock.Unlock()
+				return res, nil
+			}))
+		}))
+
+	for _, values := range [][]string{{"a", "b"}, {"c"}} {
+		values := values
+		g := rosie.Group("test-group")
+		g.Beginning().
+			Then(rosie.Fn("stub", func(_ context.Context, _ io.Writer, _ rosie.Resulter) (interface{}, error) {
+				return values, nil
+			})).
+			Then(each.Clone())
+
+		testrunner.Run(t, g, noError)
+	}
+
+	sort.Strings(got)
+	if exp := "a,b,c"; strings.Join(got, ",") != exp {
+		t.Errorf("wrong records, expected %s but got %v", exp, got)
+	}
+}
+
+func TestGroupTask_Validate(t *testing.T) {
+	lint := rosie.Group("lint")
+	lint.Beginning().
+		Then(rosie.Cmd("vet", "go", "vet", "./..."))
+
+	build := rosie.Cmd("build", "go", "build", "./...")
+	first := rosie.Group("first")
+	first.Beginning().
+		Then(build)
+
+	if err := first.Validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	second := rosie.Group("second")
+	build.Then(lint)
+	second.Beginning().Then(lint)
+
+	err := first.Validate()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "first/lint: attached to multiple graphs") {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,244 @@
+package rosie
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// HTTPTask is a type of task that sends HTTP requests, see HTTP.
+type HTTPTask struct {
+	*FnTask
+	req *httpRequest
+}
+
+// httpRequest is the configuration shared by an HTTPTask and its clones, it must not be changed once the workflow runs.
+type httpRequest struct {
+	method, url, body string
+	headers           [][2]string
+	retries           int
+	retryAny          bool
+	delay             time.Duration
+	client            *http.Client
+	into              reflect.Type
+}
+
+// HTTPResponse is the result of HTTPTask.
+type HTTPResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	// JSON is the decoded body of responses with JSON content type, see HTTPTask.Into.
+	JSON interface{}
+}
+
+// HTTPError is returned by HTTPTask if the server responded with 4xx or 5xx status.
+type HTTPError struct {
+	Status int
+	Body   []byte
+}
+
+// Error implements error interface.
+func (e *HTTPError) Error() string {
+	const max = 200
+
+	body := strings.TrimSpace(string(e.Body))
+	if len(body) > max {
+		body = body[:max] + "..."
+	}
+	if body == "" {
+		return fmt.Sprintf("unexpected status %d %s", e.Status, http.StatusText(e.Status))
+	}
+	return fmt.Sprintf("unexpected status %d %s: %s", e.Status, http.StatusText(e.Status), body)
+}
+
+// HTTP instantiates a task that sends a request and produces *HTTPResponse.
+// The URL, headers and body are templates, executed like the ones of Cmd, e.g. https://registry/tags/[[.Result.Value]].
+// The request is cancelled along with the context, 4xx and 5xx responses make the task fail.
+func HTTP(name, method, urlTemplate string) *HTTPTask {
+	req := &httpRequest{
+		method: method,
+		url:    urlTemplate,
+		delay:  time.Second,
+		client: http.DefaultClient,
+	}
+	t := &HTTPTask{req: req}
+	t.FnTask = DescribedFn(name, method+" "+urlTemplate, req.do)
+	return t
+}
+
+// Header adds a header, the value is a template.
+func (t *HTTPTask) Header(key, valueTemplate string) *HTTPTask {
+	t.req.headers = append(t.req.headers, [2]string{key, valueTemplate})
+	return t
+}
+
+// Body sets the body of the request, it is a template.
+func (t *HTTPTask) Body(bodyTemplate string) *HTTPTask {
+	t.req.body = bodyTemplate
+	return t
+}
+
+// Retry makes the request sent again, up to n more times, if it failed with 5xx status or a network error.
+// Network errors are retried only for idempotent methods (e.g. GET or PUT), the server might have processed a POST already,
+// see RetryNonIdempotent. The delay doubles after each attempt.
+func (t *HTTPTask) Retry(n int, delay time.Duration) *HTTPTask {
+	t.req.retries = n
+	t.req.delay = delay
+	return t
+}
+
+// RetryNonIdempotent makes network errors retried for all methods, e.g. if the server tolerates duplicated POST requests.
+func (t *HTTPTask) RetryNonIdempotent() *HTTPTask {
+	t.req.retryAny = true
+	return t
+}
+
+// Client replaces http.DefaultClient.
+func (t *HTTPTask) Client(c *http.Client) *HTTPTask {
+	t.req.client = c
+	return t
+}
+
+// Into makes JSON bodies decoded into fresh values of the type into points to, instead of generic maps and slices.
+// It panics if into is not a pointer.
+func (t *HTTPTask) Into(into interface{}) *HTTPTask {
+	typ := reflect.TypeOf(into)
+	if typ == nil || typ.Kind() != reflect.Ptr {
+		panic(&InitError{
+			msg: fmt.Sprintf("decoding target has to be a pointer, got %T", into),
+		})
+	}
+	t.req.into = typ
+	return t
+}
+
+func (r *httpRequest) do(ctx context.Context, w io.Writer, res Resulter) (interface{}, error) {
+	data := templateData{
+		Result: templateResult{res.Result()},
+		Params: ParamsFrom(ctx),
+	}
+	url, err := render("url", r.url, data)
+	if err != nil {
+		return nil, err
+	}
+	body, err := render("body", r.body, data)
+	if err != nil {
+		return nil, err
+	}
+	header := make(http.Header, len(r.headers))
+	for _, h := range r.headers {
+		val, err := render(h[0], h[1], data)
+		if err != nil {
+			return nil, err
+		}
+		header.Add(h[0], val)
+	}
+
+	delay := r.delay
+	for attempt := 0; ; attempt++ {
+		resp, err := r.send(ctx, url, header, body)
+		if err == nil {
+			_, err = fmt.Fprintf(w, "%s %s: %d %s %dB\n", r.method, url, resp.Status, http.StatusText(resp.Status), len(resp.Body))
+			if err != nil {
+				return nil, err
+			}
+			if resp.Status < 400 {
+				if err := r.decode(resp); err != nil {
+					return nil, err
+				}
+				return resp, nil
+			}
+			err = &HTTPError{Status: resp.Status, Body: resp.Body}
+		}
+
+		if attempt >= r.retries || !r.retryable(ctx, err) {
+			return nil, err
+		}
+		if _, err := fmt.Fprintf(w, "%s, retrying in %s\n", err, delay); err != nil {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (r *httpRequest) send(ctx context.Context, url string, header http.Header, body string) (*HTTPResponse, error) {
+	var rd io.Reader
+	if body != "" {
+		rd = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(r.method, url, rd)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := r.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	buf, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &HTTPResponse{
+		Status: resp.StatusCode,
+		Header: resp.Header,
+		Body:   buf,
+	}, nil
+}
+
+// decode decodes the body of the response if it has JSON content type.
+func (r *httpRequest) decode(resp *HTTPResponse) error {
+	mt, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || (mt != "application/json" && !strings.HasSuffix(mt, "+json")) || len(resp.Body) == 0 {
+		return nil
+	}
+
+	if r.into == nil {
+		return json.Unmarshal(resp.Body, &resp.JSON)
+	}
+	val := reflect.New(r.into.Elem()).Interface()
+	if err := json.NewDecoder(bytes.NewReader(resp.Body)).Decode(val); err != nil {
+		return err
+	}
+	resp.JSON = val
+	return nil
+}
+
+// retryable reports whether the request failed because of 5xx status or the network, and not because of the context.
+func (r *httpRequest) retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if he, ok := err.(*HTTPError); ok {
+		return he.Status >= 500
+	}
+	return r.retryAny || idempotent(r.method)
+}
+
+// idempotent reports whether sending the request again has the same effect as sending it once, see RFC 7231 section 4.2.2.
+func idempotent(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
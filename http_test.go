@@ -0,0 +1,135 @@
+package rosie_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/runner/testrunner"
+)
+
+func TestHTTP(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("X-Request", r.Header.Get("X-Request"))
+		_, _ = fmt.Fprintf(w, `{"method": %q, "path": %q, "body": %q}`, r.Method, r.URL.Path, body)
+	}))
+	defer srv.Close()
+
+	type echo struct {
+		Method, Path, Body string
+	}
+
+	g := rosie.Group("http")
+	g.Beginning().
+		Then(rosie.Fn("tag", func(_ context.Context, _ io.Writer, _ rosie.Resulter) (interface{}, error) {
+			return "v1.2.3", nil
+		})).
+		Then(rosie.HTTP("deploy", http.MethodPost, srv.URL+"/[[.Params.app]]/[[.Result.Value]]").
+			Header("X-Request", "deploy-[[.Result.Value]]").
+			Body(`{"tag": "[[.Result.Value]]"}`).
+			Retry(2, time.Millisecond).
+			Into(&echo{}))
+
+	ctx := rosie.WithParams(context.Background(), map[string]interface{}{"app": "rosie"})
+	rec := testrunner.ExecuteContext(ctx, t, g)
+	if rec.Err != nil {
+		t.Fatal(rec.Err)
+	}
+
+	testrunner.AssertOutputContains(t, rec, "deploy", "retrying")
+	resp, ok := rec.Task("deploy").Result.(*rosie.HTTPResponse)
+	if !ok {
+		t.Fatalf("unexpected result: %#v", rec.Task("deploy").Result)
+	}
+	if resp.Status != http.StatusOK || resp.Header.Get("X-Request") != "deploy-v1.2.3" {
+		t.Errorf("unexpected response: %d %v", resp.Status, resp.Header)
+	}
+	exp := &echo{Method: "POST", Path: "/rosie/v1.2.3", Body: `{"tag": "v1.2.3"}`}
+	if got, ok := resp.JSON.(*echo); !ok || *got != *exp {
+		t.Errorf("unexpected body: %#v", resp.JSON)
+	}
+}
+
+func TestHTTP_pessimistic(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+		case "/drop":
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				_ = conn.Close()
+			}
+		default:
+			http.Error(w, "broken", http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	cases := map[string]struct {
+		method   string
+		path     string
+		retryAny bool
+		timeout  time.Duration
+		calls    int32
+	}{
+		"client-error-not-retried":   {path: "/missing", calls: 1},
+		"server-error-retried":       {path: "/broken", calls: 3},
+		"timeout":                    {path: "/slow", timeout: 10 * time.Millisecond, calls: 1},
+		"post-server-error-retried":  {method: http.MethodPost, path: "/broken", calls: 3},
+		"post-network-error":         {method: http.MethodPost, path: "/drop", calls: 1},
+		"post-network-error-retried": {method: http.MethodPost, path: "/drop", retryAny: true, calls: 3},
+	}
+
+	for hint, c := range cases {
+		t.Run(hint, func(t *testing.T) {
+			atomic.StoreInt32(&calls, 0)
+
+			ctx := context.Background()
+			if c.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, c.timeout)
+				defer cancel()
+			}
+
+			method := c.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			task := rosie.HTTP("request", method, srv.URL+c.path).Retry(2, time.Millisecond)
+			if c.retryAny {
+				task.RetryNonIdempotent()
+			}
+
+			g := rosie.Group("http")
+			g.Beginning().Then(task)
+
+			rec := testrunner.ExecuteContext(ctx, t, g)
+			if rec.Err == nil {
+				t.Fatal("error expected")
+			}
+			testrunner.AssertFailed(t, rec, "request")
+			if got := atomic.LoadInt32(&calls); got != c.calls {
+				t.Errorf("unexpected number of calls: %d, expected %d", got, c.calls)
+			}
+		})
+	}
+}
@@ -10,13 +10,21 @@ import (
 
 const (
 	space = "\u2004"
+	// Width is the default number of columns.
 	Width = 150
+	// Height is the default number of rows.
+	Height = 24
 )
 
 type Char string
 
 type Drawer struct {
-	W                           io.Writer
+	W io.Writer
+	// Columns limits the width of the output, Width is used if zero.
+	Columns int
+	// NoColor strips ANSI escape sequences from the output.
+	NoColor bool
+
 	depth, level                int
 	used                        bool
 	previousLevel               int
@@ -24,6 +32,24 @@ type Drawer struct {
 	sections                    int
 }
 
+// New creates a drawer that fits the terminal the writer refers to.
+// Colors are enabled only if the writer is a terminal and NO_COLOR is not set.
+func New(w io.Writer) *Drawer {
+	return &Drawer{
+		W:       w,
+		Columns: DetectWidth(w),
+		NoColor: !ColorEnabled(w),
+	}
+}
+
+// Width returns the number of columns the output is limited to.
+func (d *Drawer) Width() int {
+	if d.Columns > 0 {
+		return d.Columns
+	}
+	return Width
+}
+
 func (d *Drawer) NewEntry(l int, s string) {
 	d.EndEntry(l)
 
@@ -99,7 +125,7 @@ func (d *Drawer) NewSection() {
 	d.EndSection(true)
 	if d.sections == 0 {
 		d.newLine(" ┠─┬", "")
-		d.NewColumn(0, d.straightLineUntilEnd(Width))
+		d.NewColumn(0, d.straightLineUntilEnd(d.Width()))
 		d.EndLine()
 	}
 
@@ -115,8 +141,12 @@ func (d *Drawer) EndSection(next bool) {
 		sign = " ┃ ├"
 	}
 	d.newLine(sign, "")
-	d.NewColumn(0, d.straightLineUntilEnd(Width))
+	d.NewColumn(0, d.straightLineUntilEnd(d.Width()))
 	d.EndLine()
+	if !next {
+		// The section is closed, it should not be closed again by the next entry.
+		d.sections = 0
+	}
 }
 
 const newLineDefaultSign = " ┃ │ "
@@ -135,12 +165,13 @@ func (d *Drawer) newLine(sign, s string) {
 
 	ps, pw := d.sprintf("%s%s%s", d.prefix(), white(sign), repeat(space, p))
 	sw := utf8.RuneCountInString(s)
+	width := d.Width()
 
-	if pw+sw > Width {
+	if pw+sw > width && width > pw {
 		for len(s) > 0 {
 			idx := utf8.RuneCountInString(s)
-			if idx > Width-pw {
-				idx = Width - pw
+			if idx > width-pw {
+				idx = width - pw
 			}
 
 			d.lineWritten += d.fprint(ps, sc, s[:idx], ec)
@@ -161,9 +192,11 @@ func (d *Drawer) NewColumn(m int, s string) {
 	ps, pw := d.sprintf("%s", repeat(space, m-d.lineWritten))
 	_, sw := d.sprintf("%s", s)
 
-	if d.lineWritten+pw+sw > Width {
+	width := d.Width()
+
+	if d.lineWritten+pw+sw > width && width > pw+d.lineWritten {
 		idx := utf8.RuneCountInString(s)
-		left := Width - pw - d.lineWritten
+		left := width - pw - d.lineWritten
 		if idx > left {
 			idx = left
 		}
@@ -184,21 +217,30 @@ func (d *Drawer) EndLine() {
 func (d *Drawer) sprintf(s string, args ...interface{}) (string, int) {
 	str := fmt.Sprintf(s, args...)
 
-	return str, utf8.RuneCountInString(str)
+	return str, utf8.RuneCountInString(StripColor(str))
 }
 
 func (d *Drawer) fprint(s ...string) int {
-	str := strings.Join(s, "")
-	_, _ = fmt.Fprint(d.W, str)
-
-	return utf8.RuneCountInString(str)
+	return d.write(strings.Join(s, ""))
 }
 
 func (d *Drawer) fprintf(s string, args ...interface{}) int {
-	str := fmt.Sprintf(s, args...)
+	return d.write(fmt.Sprintf(s, args...))
+}
+
+// write writes the string and returns the number of visible characters.
+func (d *Drawer) write(str string) int {
+	str = d.sanitize(str)
 	_, _ = fmt.Fprint(d.W, str)
 
-	return utf8.RuneCountInString(str)
+	return utf8.RuneCountInString(StripColor(str))
+}
+
+func (d *Drawer) sanitize(s string) string {
+	if d.NoColor {
+		return StripColor(s)
+	}
+	return s
 }
 
 func (d *Drawer) straightLineUntilEnd(l int) string {
@@ -222,9 +264,22 @@ func white(s string) string {
 }
 
 var (
-	colorExpression = regexp.MustCompile(`^(?P<opening>\033\[[0-9]{0,2}m).*(?P<closing>\033\[0m)$`)
+	colorExpression  = regexp.MustCompile(`^(?P<opening>\033\[[0-9]{0,2}m).*(?P<closing>\033\[0m)$`)
+	escapeExpression = regexp.MustCompile(`\033\[[0-9;]*m`)
+	// controlExpression matches CSI (e.g. colors, cursor movements), OSC (e.g. hyperlinks) and other escape sequences.
+	controlExpression = regexp.MustCompile(`\033(\[[0-9;?]*[ -/]*[@-~]|\][^\007\033]*(\007|\033\\)?|[@-Z\\-_])`)
 )
 
+// StripColor removes ANSI color escape sequences.
+func StripColor(s string) string {
+	return escapeExpression.ReplaceAllString(s, "")
+}
+
+// StripEscapes removes all ANSI escape sequences, not only colors, e.g. before the width of the text is measured.
+func StripEscapes(s string) string {
+	return controlExpression.ReplaceAllString(s, "")
+}
+
 func retrieveColor(s string) (string, string, bool) {
 	if !colorExpression.MatchString(s) {
 		return "", "", false
@@ -0,0 +1,43 @@
+package draw
+
+import (
+	"bytes"
+	"io"
+)
+
+// Lines draws plain lines without any decoration, e.g. for logs collected by CI systems.
+// Entries and sections are written as regular lines, columns are appended to the current line.
+type Lines struct {
+	W io.Writer
+	// NoColor strips ANSI escape sequences from the output.
+	NoColor bool
+
+	line bytes.Buffer
+}
+
+func (l *Lines) NewEntry(_ int, s string) {
+	l.NewLine(s)
+	l.EndLine()
+}
+
+func (l *Lines) EndEntry(int) {}
+
+func (l *Lines) NewSection() {}
+
+func (l *Lines) NewColumn(_ int, s string) {
+	l.line.WriteString(s)
+}
+
+func (l *Lines) NewLine(s string) {
+	l.line.Reset()
+	l.line.WriteString(s)
+}
+
+func (l *Lines) EndLine() {
+	s := l.line.String()
+	if l.NoColor {
+		s = StripColor(s)
+	}
+	l.line.Reset()
+	_, _ = io.WriteString(l.W, s+"\n")
+}
@@ -0,0 +1,57 @@
+package draw
+
+import (
+	"io"
+	"os"
+	"strconv"
+)
+
+// IsTerminal reports whether the writer is a terminal.
+func IsTerminal(w io.Writer) bool {
+	_, _, ok := terminalSize(w)
+	return ok
+}
+
+// DetectWidth returns the number of columns available for the output.
+// The COLUMNS environment variable takes precedence over the size of the terminal, Width is returned if neither is known.
+func DetectWidth(w io.Writer) int {
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	if cols, _, ok := terminalSize(w); ok {
+		return cols
+	}
+	return Width
+}
+
+// DetectHeight returns the number of rows available for the output.
+// The LINES environment variable takes precedence over the size of the terminal, Height is returned if neither is known.
+func DetectHeight(w io.Writer) int {
+	if rows, err := strconv.Atoi(os.Getenv("LINES")); err == nil && rows > 0 {
+		return rows
+	}
+	if _, rows, ok := terminalSize(w); ok && rows > 0 {
+		return rows
+	}
+	return Height
+}
+
+// ColorEnabled reports whether ANSI escape sequences should be written.
+// Colors are disabled if NO_COLOR is set to a non-empty value (https://no-color.org), TERM is dumb or the writer is not a terminal.
+func ColorEnabled(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTerminal(w)
+}
+
+func terminalSize(w io.Writer) (int, int, bool) {
+	f, ok := w.(interface{ Fd() uintptr })
+	if !ok {
+		return 0, 0, false
+	}
+	return windowSize(f.Fd())
+}
@@ -0,0 +1,9 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package draw
+
+// windowSize is not supported on this platform, the output is treated as if it was not a terminal.
+func windowSize(uintptr) (int, int, bool) {
+	return 0, 0, false
+}
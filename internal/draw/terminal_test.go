@@ -0,0 +1,110 @@
+package draw_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/travelaudience/rosie/internal/draw"
+)
+
+// restoreEnv brings back the environment variable as it was when restoreEnv was called, including being unset.
+func restoreEnv(key string) func() {
+	value, ok := os.LookupEnv(key)
+	return func() {
+		if ok {
+			_ = os.Setenv(key, value)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	}
+}
+
+func TestDetectWidth(t *testing.T) {
+	defer restoreEnv("COLUMNS")()
+
+	b := bytes.NewBuffer(nil)
+	if draw.IsTerminal(b) {
+		t.Error("buffer is not a terminal")
+	}
+
+	os.Setenv("COLUMNS", "")
+	if got := draw.DetectWidth(b); got != draw.Width {
+		t.Errorf("expected default width, got %d", got)
+	}
+	os.Setenv("COLUMNS", "80")
+	if got := draw.DetectWidth(b); got != 80 {
+		t.Errorf("expected width from COLUMNS, got %d", got)
+	}
+}
+
+func TestDetectHeight(t *testing.T) {
+	defer restoreEnv("LINES")()
+
+	b := bytes.NewBuffer(nil)
+	os.Setenv("LINES", "")
+	if got := draw.DetectHeight(b); got != draw.Height {
+		t.Errorf("expected default height, got %d", got)
+	}
+	os.Setenv("LINES", "40")
+	if got := draw.DetectHeight(b); got != 40 {
+		t.Errorf("expected height from LINES, got %d", got)
+	}
+}
+
+func TestStripEscapes(t *testing.T) {
+	in := "\033[1;31mred\033[0m \033[2Kline \033]8;;http://example.com\007link\033]8;;\007"
+	if got := draw.StripEscapes(in); got != "red line link" {
+		t.Errorf("unexpected result: %q", got)
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	defer restoreEnv("NO_COLOR")()
+
+	os.Setenv("NO_COLOR", "1")
+	if draw.ColorEnabled(os.Stdout) {
+		t.Error("colors should be disabled if NO_COLOR is set")
+	}
+	os.Unsetenv("NO_COLOR")
+	if draw.ColorEnabled(bytes.NewBuffer(nil)) {
+		t.Error("colors should be disabled if the output is not a terminal")
+	}
+}
+
+func TestDrawer_NoColor(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	d := &draw.Drawer{W: b, Columns: 40, NoColor: true}
+
+	d.NewEntry(0, "\033[93mentry\033[0m")
+	d.NewSection()
+	d.NewLine("\033[34m" + strings.Repeat("x", 100) + "\033[0m")
+	d.EndLine()
+	d.EndEntry(0)
+
+	if strings.Contains(b.String(), "\033") {
+		t.Errorf("escape sequences written: %q", b.String())
+	}
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		if n := len([]rune(line)); n > 40 {
+			t.Errorf("line exceeds the width (%d): %s", n, line)
+		}
+	}
+}
+
+func TestLines(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	d := &draw.Lines{W: b, NoColor: true}
+
+	d.NewEntry(1, "entry")
+	d.NewSection()
+	d.NewLine("\033[34mfirst\033[0m")
+	d.NewColumn(10, ": second")
+	d.EndLine()
+	d.EndEntry(0)
+
+	if got, exp := b.String(), "entry\nfirst: second\n"; got != exp {
+		t.Errorf("unexpected output: %q", got)
+	}
+}
@@ -0,0 +1,26 @@
+// Package syncio provides io primitives that are safe for concurrent use.
+package syncio
+
+import (
+	"io"
+	"sync"
+)
+
+// Writer serializes writes to the underlying writer, e.g. of standard output and error of a command.
+type Writer struct {
+	w    io.Writer
+	lock sync.Mutex
+}
+
+// NewWriter returns a Writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write implements io.Writer interface.
+func (s *Writer) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.w.Write(p)
+}
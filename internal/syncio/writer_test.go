@@ -0,0 +1,31 @@
+package syncio_test
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/travelaudience/rosie/internal/syncio"
+)
+
+func TestWriter(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w := syncio.NewWriter(buf)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, _ = w.Write([]byte("line\n"))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := strings.Count(buf.String(), "line\n"); got != 1000 {
+		t.Errorf("unexpected number of lines: %d", got)
+	}
+}
@@ -9,6 +9,7 @@ import (
 // Iterator ...
 type Iterator struct {
 	*dag.Walker
+	err error
 }
 
 func newIterator(node *dag.Node) (*Iterator, error) {
@@ -21,15 +22,20 @@ func newIterator(node *dag.Node) (*Iterator, error) {
 	}, nil
 }
 
-// Next ...
+// Next returns the next task that is ready to be processed.
+// It returns false once there is nothing left or the iteration failed, in which case Err returns the reason.
 func (i *Iterator) Next() (Joint, bool) {
+	if i.err != nil {
+		return nil, false
+	}
+
 Start:
 	node, err := i.Walk()
 	if err != nil {
-		if err == io.EOF {
-			return nil, false
+		if err != io.EOF {
+			i.err = err
 		}
-		panic(err) // TODO: do better
+		return nil, false
 	}
 
 	switch data := node.Data.(type) {
@@ -47,3 +53,10 @@ Start:
 
 	goto Start
 }
+
+// Err returns the error that stopped the iteration, if any.
+// Similarly to bufio.Scanner, it should be checked once Next returns false.
+// If some tasks could not be reached, it returns *dag.BrokenGraphError that lists them.
+func (i *Iterator) Err() error {
+	return i.err
+}
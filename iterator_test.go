@@ -0,0 +1,58 @@
+package rosie_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/dag"
+)
+
+func TestIterator_Err(t *testing.T) {
+	g := rosie.Group("test-group")
+	g.Beginning().
+		Then(rosie.Fn("stub", func(_ context.Context, _ io.Writer, _ rosie.Resulter) (interface{}, error) {
+			return []string{"a", "b"}, nil
+		})).
+		Then(rosie.ForEach("each", func(key string) rosie.Attacher {
+			return rosie.Fn("fail", rosie.StringClosure(func(_ context.Context, _ io.Writer, res string) (interface{}, error) {
+				if res == "a" {
+					return nil, errors.New("failure")
+				}
+				return res, nil
+			}))
+		}))
+
+	iter, err := g.Iter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		tsk, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if rnr, ok := tsk.(rosie.Executor); ok {
+			out, err := rnr.Exec(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for range out {
+			}
+		}
+	}
+
+	err = iter.Err()
+	if _, ok := err.(*dag.BrokenGraphError); !ok {
+		t.Fatalf("expected broken graph error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "test-group/for-each(each)/for-each(each)-gather-slice (waiting for: test-group/for-each(each)/fail)") {
+		t.Errorf("unexpected error message: %s", err)
+	}
+	if _, ok := iter.Next(); ok {
+		t.Error("iterator should not proceed after failure")
+	}
+}
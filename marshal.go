@@ -0,0 +1,331 @@
+package rosie
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+
+	"github.com/travelaudience/rosie/pkg/vars"
+)
+
+// UnmarshalOpts controls how files are decoded by UnmarshalFileWith.
+type UnmarshalOpts struct {
+	// Strict makes decoding fail if a file contains fields that the target type does not have.
+	Strict bool
+}
+
+// UnmarshalFile decodes files, the format is chosen by the extension:
+// .yaml/.yml (multiple documents require a slice), .json, .toml or .env (values are strings).
+// The previous task has to produce a path, a glob pattern or a []string of paths.
+// Each file is decoded into a fresh value of the type into points to, into itself is never modified,
+// so the task can be cloned (e.g. by ForEach) and executed many times.
+// A single path produces the pointer to the decoded value, otherwise the result is a map[string]interface{} of path to value.
+// It panics if into is not a pointer.
+func UnmarshalFile(into interface{}) *FnTask {
+	return UnmarshalFileWith(into, UnmarshalOpts{})
+}
+
+// UnmarshalFileWith is like UnmarshalFile, the options control decoding.
+func UnmarshalFileWith(into interface{}, opts UnmarshalOpts) *FnTask {
+	typ := reflect.TypeOf(into)
+	if typ == nil || typ.Kind() != reflect.Ptr {
+		panic(&InitError{
+			msg: fmt.Sprintf("unmarshal target has to be a pointer, got %T", into),
+		})
+	}
+
+	unmarshal := func(w io.Writer, filePath string, val interface{}) (interface{}, error) {
+		/* #nosec */
+		buf, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = fmt.Fprintf(w, "file %s read %dB\n", filePath, len(buf)); err != nil {
+			return nil, err
+		}
+
+		ext := strings.ToLower(filepath.Ext(filePath))
+		if err := decode(ext, buf, val, opts.Strict); err != nil {
+			return nil, fmt.Errorf("file %s cannot be unmarshaled: %s", filePath, err)
+		}
+
+		if _, err = fmt.Fprintf(w, "file content unmarshaled using %s unmarshaller\n", ext); err != nil {
+			return nil, err
+		}
+
+		return val, nil
+	}
+
+	return Fn("unmarshal-file", func(_ context.Context, w io.Writer, res Resulter) (interface{}, error) {
+		var paths []string
+		switch v := res.Result().Value().(type) {
+		case string:
+			if !hasMeta(v) {
+				return unmarshal(w, v, reflect.New(typ.Elem()).Interface())
+			}
+			matches, err := expand(v)
+			if err != nil {
+				return nil, err
+			}
+			paths = matches
+		case []string:
+			paths = v
+		default:
+			return nil, TypeError(reflect.String, v)
+		}
+
+		values := make(map[string]interface{}, len(paths))
+		for _, p := range paths {
+			val, err := unmarshal(w, p, reflect.New(typ.Elem()).Interface())
+			if err != nil {
+				return nil, err
+			}
+			values[p] = val
+		}
+		return values, nil
+	})
+}
+
+// MarshalOpts controls how values are written by MarshalFileWith.
+type MarshalOpts struct {
+	// Indent is a single level of indentation of JSON and TOML, it defaults to two spaces.
+	Indent string
+	// Mode is the permission of the file, it defaults to 0644.
+	Mode os.FileMode
+	// Atomic makes the file written into a temporary one first and renamed, so readers never observe a partial file.
+	Atomic bool
+}
+
+// MarshalFile writes the result of the previous task into a file, creating missing parent directories.
+// The format is chosen by the extension, the same ones as UnmarshalFile supports.
+// Env files require a map or a struct of scalar values.
+// It produces the path of the file.
+func MarshalFile(path string) *FnTask {
+	return MarshalFileWith(path, MarshalOpts{})
+}
+
+// MarshalFileWith is like MarshalFile, the options control encoding and writing.
+func MarshalFileWith(path string, opts MarshalOpts) *FnTask {
+	if opts.Indent == "" {
+		opts.Indent = "  "
+	}
+	if opts.Mode == 0 {
+		opts.Mode = 0644
+	}
+
+	return DescribedFn("marshal-file", "marshal "+path, func(_ context.Context, w io.Writer, res Resulter) (interface{}, error) {
+		ext := strings.ToLower(filepath.Ext(path))
+		buf, err := encode(ext, res.Result().Value(), opts.Indent)
+		if err != nil {
+			return nil, fmt.Errorf("file %s cannot be marshaled: %s", path, err)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+		if opts.Atomic {
+			err = writeFileAtomic(path, buf, opts.Mode)
+		} else {
+			err = ioutil.WriteFile(path, buf, opts.Mode)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = fmt.Fprintf(w, "value marshaled using %s marshaller into file %s %dB\n", ext, path, len(buf)); err != nil {
+			return nil, err
+		}
+
+		return path, nil
+	})
+}
+
+// encode encodes the value in the format given by the extension.
+func encode(ext string, val interface{}, indent string) ([]byte, error) {
+	switch ext {
+	case vars.ExtYAML, vars.ExtYML:
+		return yaml.Marshal(val)
+	case vars.ExtJSON:
+		buf, err := json.MarshalIndent(val, "", indent)
+		if err != nil {
+			return nil, err
+		}
+		return append(buf, '\n'), nil
+	case vars.ExtTOML:
+		buf := bytes.NewBuffer(nil)
+		enc := toml.NewEncoder(buf)
+		enc.Indent = indent
+		if err := enc.Encode(val); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case vars.ExtEnv:
+		// The value is turned into a JSON object first, so structs are encoded the same way they are decoded.
+		raw, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		var env map[string]interface{}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		if err := dec.Decode(&env); err != nil {
+			return nil, fmt.Errorf("env file requires a map or a struct, got %T", val)
+		}
+
+		keys := make([]string, 0, len(env))
+		for k := range env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		buf := bytes.NewBuffer(nil)
+		for _, k := range keys {
+			switch v := env[k].(type) {
+			case map[string]interface{}, []interface{}:
+				return nil, fmt.Errorf("env file requires scalar values, %s is %T", k, v)
+			case nil:
+				_, _ = fmt.Fprintf(buf, "%s=\n", k)
+			case string:
+				if strings.ContainsAny(v, " \t\n\"'#\\") {
+					v = strconv.Quote(v)
+				}
+				_, _ = fmt.Fprintf(buf, "%s=%s\n", k, v)
+			default:
+				_, _ = fmt.Fprintf(buf, "%s=%v\n", k, v)
+			}
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported extension %q", ext)
+	}
+}
+
+// decode decodes the content of a file with the given extension into the pointer.
+func decode(ext string, buf []byte, into interface{}, strict bool) error {
+	switch ext {
+	case vars.ExtYAML, vars.ExtYML:
+		return decodeYAML(buf, into, strict)
+	case vars.ExtJSON:
+		dec := json.NewDecoder(bytes.NewReader(buf))
+		if strict {
+			dec.DisallowUnknownFields()
+		}
+		return dec.Decode(into)
+	case vars.ExtTOML:
+		md, err := toml.Decode(string(buf), into)
+		if err != nil {
+			return err
+		}
+		if undecoded := md.Undecoded(); strict && len(undecoded) > 0 {
+			keys := make([]string, 0, len(undecoded))
+			for _, k := range undecoded {
+				keys = append(keys, k.String())
+			}
+			return fmt.Errorf("unknown fields: %s", strings.Join(keys, ", "))
+		}
+		return nil
+	case vars.ExtEnv:
+		env, err := parseEnv(buf)
+		if err != nil {
+			return err
+		}
+		if m, ok := into.(*map[string]string); ok {
+			*m = env
+			return nil
+		}
+		// Other types are decoded the same way as JSON objects of strings, e.g. fields need json tags.
+		raw, err := json.Marshal(env)
+		if err != nil {
+			return err
+		}
+		return decode(vars.ExtJSON, raw, into, strict)
+	default:
+		return fmt.Errorf("unsupported extension %q", ext)
+	}
+}
+
+// decodeYAML decodes a single document into the pointer, multiple documents are decoded into elements of a slice.
+func decodeYAML(buf []byte, into interface{}, strict bool) error {
+	var docs int
+	dec := yaml.NewDecoder(bytes.NewReader(buf))
+	for {
+		var doc interface{}
+		if err := dec.Decode(&doc); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		docs++
+	}
+
+	if docs <= 1 {
+		if strict {
+			return yaml.UnmarshalStrict(buf, into)
+		}
+		return yaml.Unmarshal(buf, into)
+	}
+
+	slice := reflect.ValueOf(into).Elem()
+	if slice.Kind() != reflect.Slice {
+		return fmt.Errorf("%d documents cannot be decoded into %T, a slice is required", docs, into)
+	}
+	dec = yaml.NewDecoder(bytes.NewReader(buf))
+	dec.SetStrict(strict)
+	for i := 0; i < docs; i++ {
+		elem := reflect.New(slice.Type().Elem())
+		if err := dec.Decode(elem.Interface()); err != nil {
+			return fmt.Errorf("document %d: %s", i+1, err)
+		}
+		slice.Set(reflect.Append(slice, elem.Elem()))
+	}
+	return nil
+}
+
+// parseEnv parses lines of KEY=VALUE pairs, values can be quoted, empty lines, comments and export keywords are ignored.
+func parseEnv(buf []byte) (map[string]string, error) {
+	env := make(map[string]string)
+	sc := bufio.NewScanner(bytes.NewReader(buf))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		eq := strings.Index(line, "=")
+		if eq < 1 {
+			return nil, fmt.Errorf("line %d: KEY=VALUE expected", n)
+		}
+		key, val := strings.TrimSpace(line[:eq]), strings.TrimSpace(line[eq+1:])
+		switch {
+		case len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"':
+			unquoted, err := strconv.Unquote(val)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", n, err)
+			}
+			val = unquoted
+		case len(val) >= 2 && val[0] == '\'' && val[len(val)-1] == '\'':
+			val = val[1 : len(val)-1]
+		default:
+			if i := strings.Index(val, " #"); i >= 0 {
+				val = strings.TrimSpace(val[:i])
+			}
+		}
+		env[key] = val
+	}
+	return env, sc.Err()
+}
@@ -0,0 +1,276 @@
+package rosie_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/runner/testrunner"
+)
+
+type config struct {
+	Name    string `json:"name" yaml:"name" toml:"name"`
+	Version string `json:"version" yaml:"version" toml:"version"`
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "rosie-marshal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func unmarshal(t *testing.T, input interface{}, task *rosie.FnTask) *testrunner.Record {
+	t.Helper()
+
+	g := rosie.Group("unmarshal")
+	g.Beginning().
+		Then(rosie.Fn("input", func(_ context.Context, _ io.Writer, _ rosie.Resulter) (interface{}, error) {
+			return input, nil
+		})).
+		Then(task)
+
+	return testrunner.Execute(t, g)
+}
+
+func TestUnmarshalFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.yml":  "name: a\nversion: v1\n",
+		"b.json": `{"name": "b", "version": "v2"}`,
+		"c.toml": "name = \"c\"\nversion = \"v3\"\n",
+		"d.env":  "# comment\nexport name=d\nversion=\"v4\" \n",
+		"e.yaml": "name: e\n---\nname: f\n",
+	})
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	cases := map[string]struct {
+		input interface{}
+		into  interface{}
+		exp   interface{}
+	}{
+		"yml": {
+			input: filepath.Join(dir, "a.yml"),
+			into:  &config{},
+			exp:   &config{Name: "a", Version: "v1"},
+		},
+		"json": {
+			input: filepath.Join(dir, "b.json"),
+			into:  &config{},
+			exp:   &config{Name: "b", Version: "v2"},
+		},
+		"toml": {
+			input: filepath.Join(dir, "c.toml"),
+			into:  &config{},
+			exp:   &config{Name: "c", Version: "v3"},
+		},
+		"env-struct": {
+			input: filepath.Join(dir, "d.env"),
+			into:  &config{},
+			exp:   &config{Name: "d", Version: "v4"},
+		},
+		"env-map": {
+			input: filepath.Join(dir, "d.env"),
+			into:  &map[string]string{},
+			exp:   &map[string]string{"name": "d", "version": "v4"},
+		},
+		"multi-document": {
+			input: filepath.Join(dir, "e.yaml"),
+			into:  &[]config{},
+			exp:   &[]config{{Name: "e"}, {Name: "f"}},
+		},
+		"glob": {
+			input: filepath.Join(dir, "[ab].*"),
+			into:  &config{},
+			exp: map[string]interface{}{
+				filepath.Join(dir, "a.yml"):  &config{Name: "a", Version: "v1"},
+				filepath.Join(dir, "b.json"): &config{Name: "b", Version: "v2"},
+			},
+		},
+		"slice": {
+			input: []string{filepath.Join(dir, "c.toml"), filepath.Join(dir, "d.env")},
+			into:  &config{},
+			exp: map[string]interface{}{
+				filepath.Join(dir, "c.toml"): &config{Name: "c", Version: "v3"},
+				filepath.Join(dir, "d.env"):  &config{Name: "d", Version: "v4"},
+			},
+		},
+	}
+
+	for hint, c := range cases {
+		t.Run(hint, func(t *testing.T) {
+			into := reflect.New(reflect.TypeOf(c.into).Elem()).Interface()
+			fresh := reflect.New(reflect.TypeOf(c.into).Elem()).Interface()
+			rec := unmarshal(t, c.input, rosie.UnmarshalFile(into))
+			if rec.Err != nil {
+				t.Fatal(rec.Err)
+			}
+			testrunner.AssertResult(t, rec, "unmarshal-file", c.exp)
+
+			if !reflect.DeepEqual(into, fresh) {
+				t.Errorf("target should not be modified: %#v", into)
+			}
+		})
+	}
+}
+
+func TestUnmarshalFile_repeated(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"e.yaml": "name: e\n---\nname: f\n",
+	})
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	into := &[]config{}
+	for i := 0; i < 2; i++ {
+		rec := unmarshal(t, filepath.Join(dir, "e.yaml"), rosie.UnmarshalFile(into))
+		if rec.Err != nil {
+			t.Fatal(rec.Err)
+		}
+		testrunner.AssertResult(t, rec, "unmarshal-file", &[]config{{Name: "e"}, {Name: "f"}})
+	}
+}
+
+func TestUnmarshalFile_pessimistic(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.yaml": "name: a\nunknown: true\n",
+		"b.json": `{"name": "b", "unknown": true}`,
+		"c.toml": "name = \"c\"\nunknown = true\n",
+		"d.env":  "name=d\nunknown=true\n",
+		"e.yaml": "name: e\n---\nname: f\n",
+		"x.txt":  "name: x",
+	})
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	cases := map[string]struct {
+		input string
+		opts  rosie.UnmarshalOpts
+	}{
+		"unknown-extension": {input: "x.txt"},
+		"multi-document":    {input: "e.yaml"},
+		"strict-yaml":       {input: "a.yaml", opts: rosie.UnmarshalOpts{Strict: true}},
+		"strict-json":       {input: "b.json", opts: rosie.UnmarshalOpts{Strict: true}},
+		"strict-toml":       {input: "c.toml", opts: rosie.UnmarshalOpts{Strict: true}},
+		"strict-env":        {input: "d.env", opts: rosie.UnmarshalOpts{Strict: true}},
+	}
+
+	for hint, c := range cases {
+		t.Run(hint, func(t *testing.T) {
+			rec := unmarshal(t, filepath.Join(dir, c.input), rosie.UnmarshalFileWith(&config{}, c.opts))
+			if rec.Err == nil {
+				t.Fatal("error expected")
+			}
+			testrunner.AssertFailed(t, rec, "unmarshal-file")
+		})
+	}
+
+	t.Run("not-pointer", func(t *testing.T) {
+		defer assertPanicInitError(t)
+		rosie.UnmarshalFile(config{})
+	})
+}
+
+func TestMarshalFile(t *testing.T) {
+	dir := writeFiles(t, nil)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	manifest := struct {
+		Name    string `json:"name" yaml:"name" toml:"name"`
+		Version string `json:"version" yaml:"version" toml:"version"`
+		Port    int    `json:"port" yaml:"port" toml:"port"`
+	}{Name: "rosie app", Version: "v1", Port: 8080}
+
+	cases := map[string]struct {
+		file string
+		opts rosie.MarshalOpts
+		exp  string
+	}{
+		"json": {
+			file: "manifest.json",
+			opts: rosie.MarshalOpts{Indent: "\t"},
+			exp:  "{\n\t\"name\": \"rosie app\",\n\t\"version\": \"v1\",\n\t\"port\": 8080\n}\n",
+		},
+		"yaml": {
+			file: "out/manifest.yml",
+			exp:  "name: rosie app\nversion: v1\nport: 8080\n",
+		},
+		"toml": {
+			file: "manifest.toml",
+			opts: rosie.MarshalOpts{Atomic: true},
+			exp:  "name = \"rosie app\"\nversion = \"v1\"\nport = 8080\n",
+		},
+		"env": {
+			file: "manifest.env",
+			opts: rosie.MarshalOpts{Mode: 0600},
+			exp:  "name=\"rosie app\"\nport=8080\nversion=v1\n",
+		},
+	}
+
+	for hint, c := range cases {
+		t.Run(hint, func(t *testing.T) {
+			path := filepath.Join(dir, c.file)
+			rec := unmarshal(t, manifest, rosie.MarshalFileWith(path, c.opts))
+			if rec.Err != nil {
+				t.Fatal(rec.Err)
+			}
+			testrunner.AssertResult(t, rec, "marshal-file", path)
+
+			buf, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(buf) != c.exp {
+				t.Errorf("unexpected content:\n%s", buf)
+			}
+
+			// Files written by MarshalFile can be read back by UnmarshalFile.
+			rec = unmarshal(t, path, rosie.UnmarshalFile(&map[string]interface{}{}))
+			if rec.Err != nil {
+				t.Fatal(rec.Err)
+			}
+		})
+	}
+
+	fi, err := os.Stat(filepath.Join(dir, "manifest.env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("unexpected mode: %s", fi.Mode())
+	}
+}
+
+func TestMarshalFile_pessimistic(t *testing.T) {
+	dir := writeFiles(t, nil)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	cases := map[string]struct {
+		file  string
+		value interface{}
+	}{
+		"unknown-extension": {file: "out.txt", value: map[string]string{}},
+		"env-nested":        {file: "out.env", value: map[string]interface{}{"a": []int{1}}},
+		"env-slice":         {file: "out.env", value: []string{"a"}},
+	}
+
+	for hint, c := range cases {
+		t.Run(hint, func(t *testing.T) {
+			rec := unmarshal(t, c.value, rosie.MarshalFile(filepath.Join(dir, c.file)))
+			if rec.Err == nil {
+				t.Fatal("error expected")
+			}
+			testrunner.AssertFailed(t, rec, "marshal-file")
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package dag
+
+import "errors"
+
+// ErrNotBeginning is returned by Clone if the given node does not begin a graph.
+var ErrNotBeginning = errors.New("rosie: dag: beginning of a graph expected")
+
+// Clone copies the graph that starts with the given beginning node, up to its end node.
+// Edges leading outside of the graph are not copied, so the copy is detached and can be attached elsewhere.
+// Data is shared between originals and copies, the returned mapping allows the caller to replace it.
+func Clone(beginning *Node) (map[*Node]*Node, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	if beginning.end == nil {
+		return nil, ErrNotBeginning
+	}
+
+	visited, _ := collect(beginning)
+	if !visited[beginning.end] {
+		return nil, ErrBrokenGraph
+	}
+
+	copies := make(map[*Node]*Node, len(visited))
+	for node := range visited {
+		copies[node] = &Node{
+			Data: node.Data,
+			kind: node.kind,
+		}
+	}
+	for node, cp := range copies {
+		if node.beginning != nil {
+			cp.beginning = copies[node.beginning]
+		}
+		if node.end != nil {
+			cp.end = copies[node.end]
+		}
+		if node != beginning {
+			for _, parent := range node.parents {
+				if visited[parent] {
+					cp.parents.add(copies[parent])
+				}
+			}
+		}
+		if node != beginning.end {
+			for _, child := range node.children {
+				cp.children.add(copies[child])
+			}
+		}
+	}
+	copies[beginning].kind = TypeBeginning
+	copies[beginning.end].kind = TypeEnd
+
+	return copies, nil
+}
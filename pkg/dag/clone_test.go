@@ -0,0 +1,46 @@
+package dag
+
+import "testing"
+
+func TestClone(t *testing.T) {
+	b, e := New()
+	b.Data = "b"
+	e.Data = "e"
+	n1 := &Node{Data: "n1"}
+	n2 := &Node{Data: "n2"}
+	n1.Between(b, e)
+	n2.Between(b, e)
+
+	outer, outerEnd := New()
+	b.Between(outer, outerEnd)
+
+	nodes, err := Clone(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 4 {
+		t.Fatalf("wrong number of copies: %d", len(nodes))
+	}
+
+	cb, ce := nodes[b], nodes[e]
+	if cb.Type() != TypeBeginning || ce.Type() != TypeEnd {
+		t.Errorf("wrong types of beginning and end: %s, %s", cb.Type(), ce.Type())
+	}
+	if cb.end != ce || ce.beginning != cb {
+		t.Error("beginning and end of the copy are not linked")
+	}
+	if len(cb.Parents()) != 0 || len(ce.Children()) != 0 {
+		t.Error("copy should not be attached to the outer graph")
+	}
+	assertContains(t, nodes[n1], cb.Children())
+	assertContains(t, nodes[n2], cb.Children())
+	assertContains(t, nodes[n1], ce.Parents())
+	assertContainsNot(t, n1, cb.Children())
+	assertContains(t, b, outer.Children())
+}
+
+func TestClone_notBeginning(t *testing.T) {
+	if _, err := Clone(&Node{}); err != ErrNotBeginning {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
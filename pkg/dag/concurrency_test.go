@@ -0,0 +1,123 @@
+package dag
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestNode_concurrentMutations(t *testing.T) {
+	const workers = 50
+
+	b, e := New()
+	b.Data, e.Data = "b", "e"
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+
+			g, ge := New()
+			g.Data, ge.Data = fmt.Sprintf("g%d", i), fmt.Sprintf("g%d-end", i)
+			(&Node{Data: fmt.Sprintf("n%d", i)}).Between(g, ge)
+			g.Between(b, e)
+		}(i)
+		go func() {
+			defer wg.Done()
+
+			_ = Validate(b)
+			_, _ = TopologicalSort(b)
+			_ = e.Parents().String()
+			_ = e.Path()
+		}()
+	}
+	wg.Wait()
+
+	if got := len(b.Children()); got != workers {
+		t.Fatalf("wrong number of children, expected %d but got %d", workers, got)
+	}
+	if err := Validate(b); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprintf("%#v", b); len(got) == 0 {
+		t.Error("empty tree")
+	}
+}
+
+func TestWalker_concurrent(t *testing.T) {
+	const workers = 20
+
+	b, e := New()
+	b.Data, e.Data = "b", "e"
+	extend := make(map[*Node]bool)
+	for i := 0; i < workers; i++ {
+		n := &Node{Data: fmt.Sprintf("n%d", i)}
+		n.Between(b, e)
+		extend[n] = true
+	}
+
+	w, err := NewWalker(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		seen = make(map[*Node]bool)
+		done = make(chan struct{})
+	)
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				_, _ = TopologicalSort(b)
+				_ = e.Path()
+			}
+		}
+	}()
+
+	// Nodes are marked as done by separate goroutines, all running at the same time as the walk,
+	// while the graph is continuously read by yet another one.
+	// The walk reports a broken graph while some of the nodes are still in progress, it is resumed once they are done.
+	inProgress := 0
+	for {
+		node, err := w.Walk()
+		if _, ok := err.(*BrokenGraphError); ok && inProgress > 0 {
+			wg.Wait()
+			inProgress = 0
+			continue
+		}
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatal(err)
+		}
+		if seen[node] {
+			t.Fatalf("node %v returned twice", node.Data)
+		}
+		seen[node] = true
+
+		// The graph is extended before the next walk, which discovers children of the node.
+		if extend[node] {
+			(&Node{Data: fmt.Sprintf("%v-child", node.Data)}).Between(node, e)
+		}
+		wg.Add(1)
+		inProgress++
+		go func(node *Node) {
+			defer wg.Done()
+
+			node.MarkAsDone()
+		}(node)
+	}
+	wg.Wait()
+
+	if exp := 1 + 2*workers; len(seen) != exp {
+		t.Errorf("wrong number of nodes walked, expected %d but got %d", exp, len(seen))
+	}
+}
@@ -0,0 +1,174 @@
+package dag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CycleError is returned (or fired as a panic by mutating methods) once a cycle is detected.
+type CycleError struct {
+	// Path starts and ends with the same node.
+	Path Nodes
+}
+
+// Error implements error interface.
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("rosie: dag: cycle detected: %s", e.Path.Path())
+}
+
+// TopologicalSort returns nodes of the graph that starts with the given beginning node (up to its end node),
+// ordered in such a way that each node comes before all of its children.
+// It returns CycleError if the graph is not acyclic.
+func TopologicalSort(beginning *Node) (Nodes, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	visited, order := collect(beginning)
+	if cycles := findCycles(beginning, visited); len(cycles) > 0 {
+		return nil, &CycleError{Path: cycles[0]}
+	}
+
+	degree := make(map[*Node]int, len(order))
+	for _, node := range order {
+		if node == beginning {
+			continue
+		}
+		for _, parent := range node.parents {
+			if visited[parent] {
+				degree[node]++
+			}
+		}
+	}
+
+	sorted := make(Nodes, 0, len(order))
+	queue := Nodes{beginning}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		sorted = append(sorted, node)
+		if node == beginning.end {
+			continue
+		}
+		for _, child := range node.children {
+			degree[child]--
+			if degree[child] == 0 {
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	return sorted, nil
+}
+
+// collect gathers all the nodes reachable from the given beginning node (up to its end node), in the order of discovery.
+func collect(beginning *Node) (map[*Node]bool, Nodes) {
+	var (
+		visited = map[*Node]bool{beginning: true}
+		order   Nodes
+		pending stack
+	)
+	pending.push(beginning)
+	for !pending.isEmpty() {
+		node, _ := pending.pop()
+		order = append(order, node)
+		if node == beginning.end {
+			continue
+		}
+		for n := len(node.children) - 1; n >= 0; n-- {
+			child := node.children[n]
+			if !visited[child] {
+				visited[child] = true
+				pending.push(child)
+			}
+		}
+	}
+
+	return visited, order
+}
+
+// checkEdge panics with CycleError if adding an edge from parent to child would create a cycle.
+func checkEdge(parent, child *Node) {
+	if path := findPath(child, parent); path != nil {
+		panic(&CycleError{Path: append(path, child)})
+	}
+}
+
+// findPath returns the path from one node to another following children, or nil if there is none.
+func findPath(from, to *Node) Nodes {
+	previous := map[*Node]*Node{from: nil}
+	queue := Nodes{from}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if node == to {
+			var path Nodes
+			for n := node; n != nil; n = previous[n] {
+				path = append(Nodes{n}, path...)
+			}
+			return path
+		}
+		for _, child := range node.children {
+			if _, ok := previous[child]; !ok {
+				previous[child] = node
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	return nil
+}
+
+// findCycles looks for cycles in the graph that starts with the given beginning node.
+// The search is limited to the given nodes, or entire graph if nil.
+// Each cycle is returned as a path that starts and ends with the same node.
+func findCycles(beginning *Node, within map[*Node]bool) []Nodes {
+	const (
+		white = iota
+		gray
+		black
+	)
+	var (
+		color  = make(map[*Node]int)
+		path   Nodes
+		cycles []Nodes
+		visit  func(*Node)
+	)
+	visit = func(n *Node) {
+		color[n] = gray
+		path = append(path, n)
+		if beginning.end != n {
+			for _, child := range n.children {
+				if within != nil && !within[child] {
+					continue
+				}
+				switch color[child] {
+				case white:
+					visit(child)
+				case gray:
+					for i := len(path) - 1; i >= 0; i-- {
+						if path[i] == child {
+							cycle := append(Nodes{}, path[i:]...)
+							cycles = append(cycles, append(cycle, child))
+							break
+						}
+					}
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		color[n] = black
+	}
+	visit(beginning)
+
+	return cycles
+}
+
+// Path returns names of the nodes joined with arrows, e.g. a -> b -> a.
+func (n Nodes) Path() string {
+	parts := make([]string, 0, len(n))
+	for _, nn := range n {
+		parts = append(parts, nn.name())
+	}
+
+	return strings.Join(parts, " -> ")
+}
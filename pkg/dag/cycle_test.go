@@ -0,0 +1,83 @@
+package dag
+
+import "testing"
+
+func TestNode_After_cycle(t *testing.T) {
+	b, e := New()
+	b.Data, e.Data = "b", "e"
+	n1 := &Node{Data: "n1"}
+	n2 := &Node{Data: "n2"}
+	b.After(n1)
+	n1.After(n2)
+
+	defer func() {
+		err, ok := recover().(*CycleError)
+		if !ok {
+			t.Fatalf("expected panic to carry CycleError, got %T", err)
+		}
+		if exp := "rosie: dag: cycle detected: n1 -> n2 -> n1"; err.Error() != exp {
+			t.Errorf("wrong message, expected %q but got %q", exp, err.Error())
+		}
+		if len(n2.Children()) != 1 || n2.Children()[0] != e {
+			t.Error("graph should remain untouched")
+		}
+	}()
+
+	n2.After(n1)
+}
+
+func TestNode_Between_cycle(t *testing.T) {
+	b, e := New()
+	b.Data, e.Data = "b", "e"
+	n := &Node{Data: "n"}
+	n.Between(b, e)
+
+	defer func() {
+		if _, ok := recover().(*CycleError); !ok {
+			t.Fatal("expected panic to carry CycleError")
+		}
+	}()
+
+	b.Between(n, e)
+}
+
+func TestTopologicalSort(t *testing.T) {
+	nodeA, nodeG := New()
+	nodeA.Data, nodeG.Data = "A", "G"
+	nodeB := &Node{Data: "B"}
+	nodeC := &Node{Data: "C"}
+	nodeF := &Node{Data: "F"}
+
+	nodeB.Between(nodeA, nodeG)
+	nodeC.Between(nodeA, nodeG)
+	nodeF.Between(nodeB, nodeG)
+	nodeF.Between(nodeC, nodeG)
+
+	sorted, err := TopologicalSort(nodeA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sorted.Path(); got != "A -> B -> C -> F -> G" {
+		t.Errorf("wrong order: %s", got)
+	}
+}
+
+func TestTopologicalSort_cycle(t *testing.T) {
+	b, e := New()
+	b.Data, e.Data = "b", "e"
+	n1 := &Node{Data: "n1"}
+	n2 := &Node{Data: "n2"}
+	n1.Between(b, e)
+	n2.Between(n1, e)
+	n2.children.add(n1)
+	n1.parents.add(n2)
+
+	_, err := TopologicalSort(b)
+	cerr, ok := err.(*CycleError)
+	if !ok {
+		t.Fatalf("expected CycleError, got %v", err)
+	}
+	if got := cerr.Path.Path(); got != "n1 -> n2 -> n1" {
+		t.Errorf("wrong cycle: %s", got)
+	}
+}
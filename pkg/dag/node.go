@@ -5,10 +5,15 @@ package dag
 import (
 	"bytes"
 	"fmt"
-	"io"
 	"strings"
+	"sync"
 )
 
+// lock guards the structure of all graphs and the status of their nodes.
+// Graphs can be merged together at any time, which makes a single lock the only safe option.
+// It is never held while calling into Data, so that Data implementations are free to use the package.
+var lock sync.RWMutex
+
 const (
 	TypeMiddle Type = iota
 	TypeBeginning
@@ -62,41 +67,146 @@ func (n *Node) isGraph() bool {
 }
 
 func (n *Node) Type() Type {
+	lock.RLock()
+	defer lock.RUnlock()
+
 	return n.kind
 }
 
 func (n *Node) Done() bool {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return n.done()
+}
+
+func (n *Node) done() bool {
 	return n.status == statusDone || n.kind == TypeBeginning
 }
 
+// Failed returns true if the node was marked as failed.
+func (n *Node) Failed() bool {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return n.status == statusFailed
+}
+
 func (n *Node) MarkAsDone() {
+	lock.Lock()
 	n.status = statusDone
+	lock.Unlock()
 }
 
 func (n *Node) MarkAsFailed() {
+	lock.Lock()
 	n.status = statusFailed
+	lock.Unlock()
 }
 
+// GoString prints the tree of nodes reachable from the node, indented by depth.
+// The value receiver copies the node, so it must not be called while the node itself is being mutated.
 func (n Node) GoString() string {
+	type line struct {
+		node  *Node
+		depth int
+	}
+	var (
+		lines []line
+		visit func(*Node, int)
+	)
+	visit = func(node *Node, depth int) {
+		lines = append(lines, line{node: node, depth: depth})
+		for _, child := range node.children {
+			visit(child, depth+1)
+		}
+	}
+
+	lock.RLock()
+	visit(&n, 0)
+	lock.RUnlock()
+
 	buf := bytes.NewBuffer(nil)
-	n.goString(buf, 0)
+	for _, l := range lines {
+		_, _ = fmt.Fprintf(buf, fmt.Sprintf("%%%ds %%s\n", l.depth*2), "", l.node.name())
+	}
 
 	return buf.String()
 }
 
-func (n Node) goString(w io.Writer, i int) {
+func (n *Node) name() string {
 	if dat, ok := n.Data.(interface{ Name() string }); ok {
-		_, _ = fmt.Fprintf(w, fmt.Sprintf("%%%ds %%s\n", i*2), "", dat.Name())
-	} else {
-		_, _ = fmt.Fprintf(w, fmt.Sprintf("%%%ds %%v\n", i*2), "", n.Data)
+		return dat.Name()
 	}
+	return fmt.Sprint(n.Data)
+}
 
-	for _, child := range n.children {
-		child.goString(w, i+1)
+// Scope returns beginnings of all the graphs that enclose the node, starting from the outermost one.
+func (n *Node) Scope() Nodes {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return n.scope()
+}
+
+func (n *Node) scope() Nodes {
+	var (
+		scope Nodes
+		seen  = make(map[*Node]bool)
+	)
+	for cur := n; !seen[cur] && len(cur.parents) > 0; {
+		seen[cur] = true
+
+		parent := cur.parents[0]
+		switch parent.kind {
+		case TypeBeginning, TypeMiddleBeginning:
+			scope = append(scope, parent)
+			cur = parent
+		case TypeMiddleEnd:
+			cur = parent
+			if parent.beginning != nil {
+				cur = parent.beginning
+			}
+		default:
+			cur = parent
+		}
 	}
+
+	for i, j := 0, len(scope)-1; i < j; i, j = i+1, j-1 {
+		scope[i], scope[j] = scope[j], scope[i]
+	}
+	return scope
+}
+
+// Path returns slash separated names of the enclosing graphs followed by the name of the node, e.g. build/go-build.
+func (n *Node) Path() string {
+	return path(n.Scope(), n)
+}
+
+func path(scope Nodes, n *Node) string {
+	parts := make([]string, 0, len(scope)+1)
+	for _, s := range scope {
+		parts = append(parts, s.name())
+	}
+
+	return strings.Join(append(parts, n.name()), "/")
 }
 
+// After attaches the given node (or graph) right after n, the children of n become children of the attached one.
+// It panics with CycleError if the operation would create a cycle.
 func (n *Node) After(node *Node) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	tail := node
+	if node.isGraph() && node.end != nil {
+		tail = node.end
+	}
+	checkEdge(n, node)
+	for _, child := range n.children {
+		checkEdge(tail, child)
+	}
+
 	for _, child := range n.children {
 		if node.isGraph() {
 			child.parents.replace(n, node.end)
@@ -119,7 +229,19 @@ func (n *Node) After(node *Node) {
 	n.children.add(node)
 }
 
+// Between places n (or a graph n is the beginning of) between the given nodes.
+// It panics with CycleError if the operation would create a cycle.
 func (n *Node) Between(beginning, end *Node) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	tail := n
+	if n.isGraph() && n.end != nil {
+		tail = n.end
+	}
+	checkEdge(beginning, n)
+	checkEdge(tail, end)
+
 	if n.isGraph() {
 		beginning.children.replace(end, n)
 		end.parents.replace(beginning, n.end)
@@ -157,12 +279,20 @@ func (n *Node) between(parent, child *Node) {
 	n.children.add(child)
 }
 
+// Children returns a snapshot of the node's children.
 func (n *Node) Children() Nodes {
-	return n.children
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return append(Nodes(nil), n.children...)
 }
 
+// Parents returns a snapshot of the node's parents.
 func (n *Node) Parents() Nodes {
-	return n.parents
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return append(Nodes(nil), n.parents...)
 }
 
 type Nodes []*Node
@@ -170,11 +300,7 @@ type Nodes []*Node
 func (n Nodes) String() string {
 	var parts []string
 	for _, nn := range n {
-		if str, ok := nn.Data.(interface{ Name() string }); ok {
-			parts = append(parts, fmt.Sprint(str.Name()))
-		} else {
-			parts = append(parts, fmt.Sprint(nn.Data))
-		}
+		parts = append(parts, nn.name())
 	}
 
 	return strings.Join(parts, "\n")
@@ -183,7 +309,7 @@ func (n Nodes) String() string {
 func (n Nodes) done() bool {
 	done := true
 	for _, node := range n {
-		if !node.Done() {
+		if !node.done() {
 			done = false
 		}
 	}
@@ -0,0 +1,171 @@
+package dag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Problem describes a single defect of a graph.
+type Problem struct {
+	// Path of the node the problem relates to, see Node.Path.
+	Path   string
+	Reason string
+}
+
+// ValidationError lists all the problems found by Validate.
+type ValidationError struct {
+	Problems []Problem
+}
+
+// Error implements error interface.
+func (e *ValidationError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("rosie: dag: invalid graph:")
+	for _, p := range e.Problems {
+		sb.WriteString("\n\t")
+		sb.WriteString(p.Path)
+		sb.WriteString(": ")
+		sb.WriteString(p.Reason)
+	}
+
+	return sb.String()
+}
+
+// Validate checks the graph that starts with the given beginning node, up to its end node.
+// It looks for cycles, nodes that never going to be reached, nodes attached to multiple graphs,
+// groups reused illegally and missing end nodes.
+// All problems found are returned at once as a ValidationError.
+func Validate(beginning *Node) error {
+	v := &validator{root: beginning}
+
+	lock.RLock()
+	v.validate()
+	lock.RUnlock()
+
+	if len(v.findings) == 0 {
+		return nil
+	}
+
+	// Names are resolved once the lock is released, as it is never held while calling into Data.
+	problems := make([]Problem, 0, len(v.findings))
+	for _, f := range v.findings {
+		args := make([]interface{}, 0, len(f.args))
+		for _, arg := range f.args {
+			switch a := arg.(type) {
+			case *Node:
+				args = append(args, a.name())
+			case Nodes:
+				args = append(args, a.Path())
+			default:
+				args = append(args, a)
+			}
+		}
+		problems = append(problems, Problem{
+			Path:   path(f.scope, f.node),
+			Reason: fmt.Sprintf(f.format, args...),
+		})
+	}
+	return &ValidationError{Problems: problems}
+}
+
+type validator struct {
+	root     *Node
+	visited  map[*Node]bool
+	order    Nodes
+	findings []finding
+}
+
+type finding struct {
+	node   *Node
+	scope  Nodes
+	format string
+	args   []interface{}
+}
+
+// report records a problem, nodes and slices of nodes passed as arguments are formatted as names and paths respectively.
+func (v *validator) report(n *Node, format string, args ...interface{}) {
+	v.findings = append(v.findings, finding{
+		node:   n,
+		scope:  n.scope(),
+		format: format,
+		args:   args,
+	})
+}
+
+func (v *validator) validate() {
+	switch {
+	case v.root.end == nil:
+		v.report(v.root, "not a beginning of a graph, end node is missing")
+		return
+	case v.root.end.beginning != v.root:
+		v.report(v.root, "end node belongs to a different graph")
+	}
+
+	v.visited, v.order = collect(v.root)
+	if !v.visited[v.root.end] {
+		v.report(v.root, "end node (%s) is unreachable", v.root.end)
+	}
+	for _, cycle := range findCycles(v.root, v.visited) {
+		v.report(cycle[0], "cycle detected: %s", cycle)
+	}
+
+	leads := v.leadingToEnd()
+	for _, n := range v.order {
+		if n != v.root {
+			for _, parent := range n.parents {
+				if !v.visited[parent] {
+					v.report(n, "attached to multiple graphs, parent (%s) does not belong to this one, it will never be reached", parent)
+				}
+			}
+		}
+		if n != v.root.end && len(n.children) == 0 {
+			v.report(n, "has no children, end node is missing")
+		} else if !leads[n] && v.visited[v.root.end] {
+			v.report(n, "end node (%s) cannot be reached from here", v.root.end)
+		}
+		if n != v.root && n != v.root.end {
+			v.validateNested(n)
+		}
+	}
+}
+
+func (v *validator) validateNested(n *Node) {
+	switch n.kind {
+	case TypeBeginning, TypeEnd:
+		v.report(n, "group is not attached properly, its type is %s", n.kind)
+	}
+	switch {
+	case n.end != nil:
+		if !v.visited[n.end] {
+			v.report(n, "group end node (%s) is unreachable", n.end)
+		}
+		if len(n.parents) > 1 {
+			v.report(n, "group is reused in %d places, attach its clone instead", len(n.parents))
+		}
+	case n.beginning != nil:
+		if !v.visited[n.beginning] {
+			v.report(n, "group beginning node (%s) does not belong to this graph", n.beginning)
+		}
+	}
+}
+
+// leadingToEnd returns nodes that the end of the graph can be reached from.
+func (v *validator) leadingToEnd() map[*Node]bool {
+	leads := map[*Node]bool{v.root.end: true}
+	var pending stack
+	pending.push(v.root.end)
+	for !pending.isEmpty() {
+		node, _ := pending.pop()
+		if node == v.root {
+			continue
+		}
+		for _, parent := range node.parents {
+			if v.visited[parent] && !leads[parent] {
+				leads[parent] = true
+				pending.push(parent)
+			}
+		}
+	}
+
+	return leads
+}
@@ -0,0 +1,128 @@
+package dag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	cases := map[string]struct {
+		init func() *Node
+		exp  []string
+	}{
+		"valid": {
+			init: func() *Node {
+				b, e := New()
+				b.Data, e.Data = "b", "e"
+				(&Node{Data: "n1"}).Between(b, e)
+				(&Node{Data: "n2"}).Between(b, e)
+				return b
+			},
+		},
+		"not-beginning": {
+			init: func() *Node {
+				return &Node{Data: "n"}
+			},
+			exp: []string{"n: not a beginning of a graph, end node is missing"},
+		},
+		"cycle": {
+			init: func() *Node {
+				b, e := New()
+				b.Data, e.Data = "b", "e"
+				n1 := &Node{Data: "n1"}
+				n2 := &Node{Data: "n2"}
+				n1.Between(b, e)
+				n2.Between(n1, e)
+				n2.children.add(n1)
+				n1.parents.add(n2)
+				return b
+			},
+			exp: []string{"b/n1: cycle detected: n1 -> n2 -> n1"},
+		},
+		"dead-end": {
+			init: func() *Node {
+				b, e := New()
+				b.Data, e.Data = "b", "e"
+				n := &Node{Data: "n"}
+				n.Between(b, e)
+				n.children.remove(e)
+				e.parents.remove(n)
+				return b
+			},
+			exp: []string{
+				"b: end node (e) is unreachable",
+				"b/n: has no children, end node is missing",
+			},
+		},
+		"multiple-graphs": {
+			init: func() *Node {
+				b1, e1 := New()
+				b2, e2 := New()
+				b1.Data, e1.Data, b2.Data, e2.Data = "b1", "e1", "b2", "e2"
+				n := &Node{Data: "n"}
+				n.Between(b1, e1)
+				n.parents.add(b2)
+				b2.children.add(n)
+				return b1
+			},
+			exp: []string{"b1/n: attached to multiple graphs, parent (b2) does not belong to this one, it will never be reached"},
+		},
+		"reused-group": {
+			init: func() *Node {
+				b, e := New()
+				gb, ge := New()
+				b.Data, e.Data, gb.Data, ge.Data = "b", "e", "g", "g-end"
+				n1 := &Node{Data: "n1"}
+				n2 := &Node{Data: "n2"}
+				n1.Between(b, e)
+				n2.Between(b, e)
+				n1.After(gb)
+				n2.After(gb)
+				return b
+			},
+			exp: []string{"b/g: group is reused in 2 places, attach its clone instead"},
+		},
+	}
+
+	for hint, c := range cases {
+		t.Run(hint, func(t *testing.T) {
+			err := Validate(c.init())
+			if len(c.exp) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			verr, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("expected validation error, got %v", err)
+			}
+			var got []string
+			for _, p := range verr.Problems {
+				got = append(got, p.Path+": "+p.Reason)
+			}
+			if strings.Join(got, "\n") != strings.Join(c.exp, "\n") {
+				t.Errorf("wrong problems, expected:\n%s\nbut got:\n%s", strings.Join(c.exp, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestNode_Path(t *testing.T) {
+	b, e := New()
+	gb, ge := New()
+	b.Data, e.Data, gb.Data, ge.Data = "build", "build-end", "go", "go-end"
+	n := &Node{Data: "go-build"}
+	after := &Node{Data: "after"}
+
+	n.Between(gb, ge)
+	gb.Between(b, e)
+	after.Between(ge, e)
+
+	if got := n.Path(); got != "build/go/go-build" {
+		t.Errorf("wrong path: %s", got)
+	}
+	if got := after.Path(); got != "build/after" {
+		t.Errorf("wrong path: %s", got)
+	}
+}
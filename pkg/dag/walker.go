@@ -2,16 +2,20 @@ package dag
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"sort"
+	"strings"
 )
 
+// Walker is safe to use while the graph is being modified, but it is not safe for concurrent use itself.
 type Walker struct {
 	stack
 	previous *Node
 }
 
 func NewWalker(root *Node) (*Walker, error) {
-	if root.kind != TypeBeginning {
+	if root.Type() != TypeBeginning {
 		return nil, errors.New("rosie: dag: start node expected")
 	}
 
@@ -23,7 +27,37 @@ func NewWalker(root *Node) (*Walker, error) {
 
 var ErrBrokenGraph = errors.New("rosie: dag: broken graph")
 
+// BrokenGraphError is returned by the walker if there are nodes left that cannot be reached,
+// because some of their parents did not complete (or are not part of the graph).
+type BrokenGraphError struct {
+	// Unreachable nodes mapped onto parents they wait for.
+	Unreachable map[*Node]Nodes
+}
+
+// Error implements error interface.
+func (e *BrokenGraphError) Error() string {
+	parts := make([]string, 0, len(e.Unreachable))
+	for node, waiting := range e.Unreachable {
+		names := make([]string, 0, len(waiting))
+		for _, w := range waiting {
+			names = append(names, w.Path())
+		}
+		parts = append(parts, fmt.Sprintf("%s (waiting for: %s)", node.Path(), strings.Join(names, ", ")))
+	}
+	sort.Strings(parts)
+
+	return fmt.Sprintf("%s: unable to reach: %s", ErrBrokenGraph, strings.Join(parts, "; "))
+}
+
+// Unwrap returns ErrBrokenGraph.
+func (e *BrokenGraphError) Unwrap() error {
+	return ErrBrokenGraph
+}
+
 func (w *Walker) Walk() (*Node, error) {
+	lock.Lock()
+	defer lock.Unlock()
+
 	var memory stack
 	defer func() {
 		for {
@@ -49,12 +83,12 @@ Start:
 	node, ok := w.pop()
 	if !ok {
 		if !memory.isEmpty() {
-			return nil, ErrBrokenGraph
+			return nil, newBrokenGraphError(memory)
 		}
 		return nil, io.EOF
 	}
 
-	if node.Done() && isMiddleType(node.kind) {
+	if node.done() && isMiddleType(node.kind) {
 		goto Start
 	}
 
@@ -70,3 +104,18 @@ Start:
 	memory.push(node)
 	goto Start
 }
+
+func newBrokenGraphError(memory stack) *BrokenGraphError {
+	err := &BrokenGraphError{Unreachable: make(map[*Node]Nodes)}
+	for n := memory.top; n != nil; n = n.nextStackNode {
+		var waiting Nodes
+		for _, parent := range n.dagNode.parents {
+			if !parent.done() {
+				waiting = append(waiting, parent)
+			}
+		}
+		err.Unreachable[n.dagNode] = waiting
+	}
+
+	return err
+}
@@ -0,0 +1,56 @@
+package render
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var dotStyles = map[string]string{
+	"Beginning":       `style=filled, fillcolor="#005cc5", fontcolor="#ffffff"`,
+	"MiddleBeginning": `style=filled, fillcolor="#3b96ff", fontcolor="#ffffff"`,
+	"End":             `style=filled, fillcolor="#d73a49", fontcolor="#ffffff"`,
+	"MiddleEnd":       `style=filled, fillcolor="#ff6e7d", fontcolor="#ffffff"`,
+	"Hidden":          `style=filled, fillcolor="#d8dadf"`,
+	"Middle":          `color="#005cc5"`,
+}
+
+// DOT writes the graph in the Graphviz DOT language, nested groups are drawn as clusters.
+func DOT(w io.Writer, g Grapher, opts Options) error {
+	gr, err := newGraph(g)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	_, _ = bw.WriteString("digraph {\n\tnode [shape=box, fontname=monospace];\n\tedge [color=\"#005cc5\"];\n")
+	dotCluster(bw, gr.top, opts, 1)
+	for _, e := range gr.edges {
+		_, _ = bw.WriteString("\t" + e.from.id + " -> " + e.to.id + ";\n")
+	}
+	_, _ = bw.WriteString("}\n")
+
+	return bw.Flush()
+}
+
+func dotCluster(w *bufio.Writer, c *cluster, opts Options, depth int) {
+	indent := strings.Repeat("\t", depth)
+	for _, n := range c.nodes {
+		label := n.label
+		style := dotStyles[n.stereotype()]
+		if a := n.annotation(); opts.Status && a != "" {
+			label += "\n" + a
+			if n.status == StatusFailed {
+				style += `, penwidth=2, color="#d73a49"`
+			}
+		}
+		_, _ = w.WriteString(indent + n.id + " [label=" + strconv.Quote(label) + ", " + style + "];\n")
+	}
+	for _, cc := range c.clusters {
+		_, _ = w.WriteString(indent + "subgraph " + cc.id + " {\n")
+		_, _ = w.WriteString(indent + "\tlabel=" + strconv.Quote(cc.label) + ";\n")
+		dotCluster(w, cc, opts, depth+1)
+		_, _ = w.WriteString(indent + "}\n")
+	}
+}
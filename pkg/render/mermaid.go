@@ -0,0 +1,58 @@
+package render
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Mermaid writes the graph as a Mermaid flowchart, nested groups are drawn as subgraphs.
+func Mermaid(w io.Writer, g Grapher, opts Options) error {
+	gr, err := newGraph(g)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	_, _ = bw.WriteString("flowchart TD\n")
+	mermaidCluster(bw, gr.top, opts, 1)
+	for _, e := range gr.edges {
+		_, _ = bw.WriteString("\t" + e.from.id + " --> " + e.to.id + "\n")
+	}
+	_, _ = bw.WriteString("\tclassDef Beginning fill:#005cc5,color:#fff\n")
+	_, _ = bw.WriteString("\tclassDef MiddleBeginning fill:#3b96ff,color:#fff\n")
+	_, _ = bw.WriteString("\tclassDef End fill:#d73a49,color:#fff\n")
+	_, _ = bw.WriteString("\tclassDef MiddleEnd fill:#ff6e7d,color:#fff\n")
+	_, _ = bw.WriteString("\tclassDef Hidden fill:#d8dadf\n")
+	_, _ = bw.WriteString("\tclassDef Middle fill:#fff,stroke:#005cc5\n")
+	if opts.Status {
+		_, _ = bw.WriteString("\tclassDef failed stroke:#d73a49,stroke-width:3px\n")
+		for _, n := range gr.nodes {
+			if n.status == StatusFailed {
+				_, _ = bw.WriteString("\tclass " + n.id + " failed\n")
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+func mermaidCluster(w *bufio.Writer, c *cluster, opts Options, depth int) {
+	indent := strings.Repeat("\t", depth)
+	for _, n := range c.nodes {
+		label := n.label
+		if a := n.annotation(); opts.Status && a != "" {
+			label += "\n" + a
+		}
+		_, _ = w.WriteString(indent + n.id + `["` + mermaidEscape(label) + `"]:::` + n.stereotype() + "\n")
+	}
+	for _, cc := range c.clusters {
+		_, _ = w.WriteString(indent + "subgraph " + cc.id + ` ["` + mermaidEscape(cc.label) + `"]` + "\n")
+		mermaidCluster(w, cc, opts, depth+1)
+		_, _ = w.WriteString(indent + "end\n")
+	}
+}
+
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\n", "<br/>").Replace(s)
+}
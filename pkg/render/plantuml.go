@@ -0,0 +1,79 @@
+package render
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// plantUMLHeader mirrors the styling of doc/graph.puml.
+const plantUMLHeader = `@startuml
+skinparam DefaultFontName SFMono-Regular,Consolas,Liberation Mono,Menlo,Courier,monospace
+skinparam DefaultFontSize 14px
+skinparam LineType ortho
+skinparam BackgroundColor #fff
+skinparam Shadowing false
+skinparam ArrowColor #005cc5
+skinparam ArrowThickness .5
+skinparam rectangle {
+	BackgroundColor white
+	BorderColor #005cc5
+	BorderThickness .5
+
+	BackgroundColor<<Hidden>> #d8dadf
+
+	BackgroundColor<<Beginning>> #005cc5
+	FontColor<<Beginning>> #fff
+
+	BackgroundColor<<MiddleBeginning>> #3b96ff
+	FontColor<<MiddleBeginning>> #fff
+
+	BackgroundColor<<End>> #d73a49
+	FontColor<<End>> #fff
+
+	BackgroundColor<<MiddleEnd>> #ff6e7d
+	FontColor<<MiddleEnd>> #fff
+}
+`
+
+// PlantUML writes the graph as a PlantUML diagram.
+// Nodes are marked with stereotypes named after their types (Beginning, MiddleBeginning, Middle, MiddleEnd, End and Hidden),
+// nested groups are drawn as GroupTask rectangles.
+func PlantUML(w io.Writer, g Grapher, opts Options) error {
+	gr, err := newGraph(g)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	_, _ = bw.WriteString(plantUMLHeader)
+	_, _ = bw.WriteString("\n")
+	plantUMLCluster(bw, gr.top, opts, 0)
+	_, _ = bw.WriteString("\n")
+	for _, e := range gr.edges {
+		_, _ = bw.WriteString(e.from.id + " -down-> " + e.to.id + "\n")
+	}
+	_, _ = bw.WriteString("\nhide stereotype\n\n@enduml\n")
+
+	return bw.Flush()
+}
+
+func plantUMLCluster(w *bufio.Writer, c *cluster, opts Options, depth int) {
+	indent := strings.Repeat("\t", depth)
+	for _, n := range c.nodes {
+		label := n.label
+		if a := n.annotation(); opts.Status && a != "" {
+			label += `\n` + a
+		}
+		_, _ = w.WriteString(indent + `rectangle "` + plantUMLEscape(label) + `" << ` + n.stereotype() + ` >> as ` + n.id + " {\n" + indent + "}\n")
+	}
+	for _, cc := range c.clusters {
+		_, _ = w.WriteString(indent + `rectangle "` + plantUMLEscape(cc.label) + `" << GroupTask >> as ` + cc.id + " {\n")
+		plantUMLCluster(w, cc, opts, depth+1)
+		_, _ = w.WriteString(indent + "}\n")
+	}
+}
+
+func plantUMLEscape(s string) string {
+	return strings.NewReplacer(`"`, `'`, "\n", `\n`).Replace(s)
+}
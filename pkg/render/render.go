@@ -0,0 +1,156 @@
+// Package render exports workflow graphs as diagrams.
+// Supported formats are PlantUML (using the same stereotypes as the diagrams in the doc directory), Graphviz DOT and Mermaid.
+package render
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/travelaudience/rosie/pkg/dag"
+)
+
+// Grapher is implemented by anything that is anchored in a graph, e.g. rosie.GroupTask.
+type Grapher interface {
+	Node() *dag.Node
+}
+
+// Options ...
+type Options struct {
+	// Status annotates each node with its status and duration, it makes sense once the workflow was run.
+	Status bool
+}
+
+// Status of a node, as presented on a diagram.
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusOK      Status = "ok"
+	StatusFailed  Status = "failed"
+)
+
+type node struct {
+	id, label string
+	kind      dag.Type
+	status    Status
+	duration  time.Duration
+	cluster   *cluster
+}
+
+type edge struct {
+	from, to *node
+}
+
+type cluster struct {
+	id, label string
+	parent    *cluster
+	nodes     []*node
+	clusters  []*cluster
+}
+
+type graph struct {
+	nodes []*node
+	edges []edge
+	// top is a pseudo cluster that holds everything that is not part of a nested group.
+	top *cluster
+}
+
+func newGraph(g Grapher) (*graph, error) {
+	root := g.Node()
+	sorted, err := dag.TopologicalSort(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		gr       = &graph{top: &cluster{}}
+		nodes    = make(map[*dag.Node]*node, len(sorted))
+		clusters = make(map[*dag.Node]*cluster)
+	)
+	// clusterOf returns the innermost nested group the given beginning node opens.
+	var clusterOf func(beginning *dag.Node) *cluster
+	clusterOf = func(beginning *dag.Node) *cluster {
+		if beginning == nil || beginning == root || nodes[beginning] == nil {
+			return gr.top
+		}
+		if c, ok := clusters[beginning]; ok {
+			return c
+		}
+		c := &cluster{
+			id:     fmt.Sprintf("cluster_%d", len(clusters)),
+			label:  name(beginning),
+			parent: clusterOf(last(beginning.Scope())),
+		}
+		c.parent.clusters = append(c.parent.clusters, c)
+		clusters[beginning] = c
+		return c
+	}
+
+	for i, n := range sorted {
+		nn := &node{
+			id:     fmt.Sprintf("n%d", i),
+			label:  name(n),
+			kind:   n.Type(),
+			status: StatusPending,
+		}
+		switch {
+		case n.Failed():
+			nn.status = StatusFailed
+		case n.Done():
+			nn.status = StatusOK
+		}
+		if d, ok := n.Data.(interface{ Duration() time.Duration }); ok {
+			nn.duration = d.Duration()
+		}
+		nodes[n] = nn
+		gr.nodes = append(gr.nodes, nn)
+	}
+	for _, n := range sorted {
+		nn := nodes[n]
+		if n.Type() == dag.TypeMiddleBeginning && n != root {
+			nn.cluster = clusterOf(n)
+		} else {
+			nn.cluster = clusterOf(last(n.Scope()))
+		}
+		nn.cluster.nodes = append(nn.cluster.nodes, nn)
+
+		for _, child := range n.Children() {
+			if to, ok := nodes[child]; ok {
+				gr.edges = append(gr.edges, edge{from: nn, to: to})
+			}
+		}
+	}
+
+	return gr, nil
+}
+
+func (n *node) stereotype() string {
+	return strings.TrimPrefix(n.kind.String(), "Type")
+}
+
+// annotation returns the status and duration of the node, it is empty for the beginning and the end of the graph.
+func (n *node) annotation() string {
+	switch {
+	case n.kind == dag.TypeBeginning || n.kind == dag.TypeEnd:
+		return ""
+	case n.duration > 0:
+		return fmt.Sprintf("%s %s", n.status, n.duration.Round(time.Microsecond))
+	default:
+		return string(n.status)
+	}
+}
+
+func name(n *dag.Node) string {
+	if dat, ok := n.Data.(interface{ Name() string }); ok {
+		return dat.Name()
+	}
+	return fmt.Sprint(n.Data)
+}
+
+func last(nodes dag.Nodes) *dag.Node {
+	if len(nodes) == 0 {
+		return nil
+	}
+	return nodes[len(nodes)-1]
+}
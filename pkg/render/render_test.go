@@ -0,0 +1,88 @@
+package render_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/render"
+	"github.com/travelaudience/rosie/pkg/runner/testrunner"
+)
+
+func workflow() *rosie.GroupTask {
+	g := rosie.Group("build")
+	g.Beginning().
+		Then(rosie.Fn("list", func(_ context.Context, _ io.Writer, _ rosie.Resulter) (interface{}, error) {
+			return []string{"a", "b"}, nil
+		})).
+		Then(rosie.ForEach("compile", func(key string) rosie.Attacher {
+			return rosie.Fn("go-build", rosie.StringClosure(func(_ context.Context, _ io.Writer, res string) (interface{}, error) {
+				if res == "b" {
+					return nil, errors.New("compilation failure")
+				}
+				return res, nil
+			}))
+		}))
+	return g
+}
+
+func TestRender(t *testing.T) {
+	cases := map[string]struct {
+		render func(io.Writer, render.Grapher, render.Options) error
+		exp    []string
+	}{
+		"plantuml": {
+			render: render.PlantUML,
+			exp: []string{
+				`rectangle "build" << Beginning >> as n0 {`,
+				`rectangle "list\nok`,
+				`rectangle "for-each(compile)" << GroupTask >> as cluster_0 {`,
+				`	rectangle "for-each(compile)\nok`,
+				`	rectangle "go-build\nfailed`,
+				`n0 -down-> n1`,
+				`@enduml`,
+			},
+		},
+		"dot": {
+			render: render.DOT,
+			exp: []string{
+				`n0 [label="build", style=filled, fillcolor="#005cc5", fontcolor="#ffffff"];`,
+				`subgraph cluster_0 {`,
+				`label="for-each(compile)";`,
+				`n0 -> n1;`,
+				`penwidth=2, color="#d73a49"`,
+			},
+		},
+		"mermaid": {
+			render: render.Mermaid,
+			exp: []string{
+				"flowchart TD",
+				`n0["build"]:::Beginning`,
+				`subgraph cluster_0 ["for-each(compile)"]`,
+				`n0 --> n1`,
+				`failed`,
+			},
+		},
+	}
+
+	g := workflow()
+	testrunner.Run(t, g, func(*testing.T, error) {})
+
+	for hint, c := range cases {
+		t.Run(hint, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			if err := c.render(buf, g, render.Options{Status: true}); err != nil {
+				t.Fatal(err)
+			}
+			for _, exp := range c.exp {
+				if !strings.Contains(buf.String(), exp) {
+					t.Errorf("missing %q in:\n%s", exp, buf.String())
+				}
+			}
+		})
+	}
+}
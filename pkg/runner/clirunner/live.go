@@ -0,0 +1,408 @@
+package clirunner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/travelaudience/rosie/internal/draw"
+	"github.com/travelaudience/rosie/pkg/dag"
+	"github.com/travelaudience/rosie/pkg/runner"
+)
+
+const (
+	defaultTail  = 5
+	liveInterval = 100 * time.Millisecond
+	tabWidth     = 8
+)
+
+var (
+	_ runner.Observer = &live{}
+
+	spinner = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+)
+
+// live redraws the whole tree of groups in place, on every event and periodically, so timers and spinners keep moving.
+// Finished groups are collapsed into a single line, failed ones stay expanded.
+// If the tree does not fit the screen, all finished tasks are collapsed and the frame is cut in the middle,
+// lines scrolled out of the screen could not be replaced anymore.
+type live struct {
+	w      io.Writer
+	width  int
+	height int
+	color  bool
+	tail   int
+	logs   *taskLogs
+
+	root   *liveNode
+	groups map[*dag.Node]*liveNode
+	tasks  map[string]*liveNode
+	// lines is the number of lines drawn by the last frame.
+	lines int
+	tick  int
+	stop  chan struct{}
+	done  chan struct{}
+	lock  sync.Mutex
+}
+
+type liveNode struct {
+	name, desc string
+	group      bool
+	status     runner.Status
+	start      time.Time
+	duration   time.Duration
+	err        error
+	log        string
+	output     []string
+	children   []*liveNode
+}
+
+func newLive(w io.Writer, width, height int, color bool, tail int) *live {
+	if tail <= 0 {
+		tail = defaultTail
+	}
+	return &live{
+		w: w,
+		// The last column is left for the cursor, otherwise some terminals wrap the line.
+		width: width - 1,
+		// The same goes for the last row, otherwise the terminal scrolls.
+		height: height - 1,
+		color:  color,
+		tail:   tail,
+	}
+}
+
+// OnWorkflowStart implements runner.Observer interface.
+func (l *live) OnWorkflowStart(wf *runner.Workflow) {
+	l.lock.Lock()
+	l.root = &liveNode{name: wf.Name, group: true, status: runner.StatusRunning, start: wf.Start}
+	l.groups = make(map[*dag.Node]*liveNode)
+	l.tasks = make(map[string]*liveNode)
+	l.lines = 0
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+	l.redraw()
+	l.lock.Unlock()
+
+	go l.loop(l.stop, l.done)
+}
+
+// OnTaskStart implements runner.Observer interface.
+func (l *live) OnTaskStart(t *runner.Task) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	node := t.Joint.Node()
+	parent := l.parent(node)
+
+	switch t.Type {
+	case dag.TypeBeginning:
+		// The outermost group is the workflow itself.
+		if parent == l.root {
+			if l.root.name == "" {
+				l.root.name = t.Name
+			}
+			l.groups[node] = l.root
+			l.tasks[t.ID] = l.root
+			break
+		}
+		fallthrough
+	case dag.TypeMiddleBeginning:
+		n := l.add(parent, t, true)
+		l.groups[node] = n
+	case dag.TypeEnd, dag.TypeMiddleEnd:
+		l.tasks[t.ID] = parent
+	default:
+		if t.Executable {
+			l.add(parent, t, false)
+		}
+	}
+	l.redraw()
+}
+
+// OnOutput implements runner.Observer interface.
+func (l *live) OnOutput(t *runner.Task, text string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	n, ok := l.tasks[t.ID]
+	if !ok || n.group {
+		return
+	}
+	n.output = append(n.output, text)
+	if len(n.output) > l.tail {
+		n.output = n.output[len(n.output)-l.tail:]
+	}
+	l.redraw()
+}
+
+// OnTaskEnd implements runner.Observer interface.
+func (l *live) OnTaskEnd(t *runner.Task) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	n, ok := l.tasks[t.ID]
+	if !ok {
+		return
+	}
+	switch {
+	case t.Err != nil:
+		n.status = runner.StatusFailed
+		n.err = t.Err
+		n.log = l.logs.path(t.ID)
+	case n.group && t.Type != dag.TypeEnd && t.Type != dag.TypeMiddleEnd:
+		// Groups are completed by their ends.
+		return
+	default:
+		n.status = runner.StatusOK
+	}
+	n.duration = time.Since(n.start)
+	l.redraw()
+}
+
+// OnWorkflowEnd implements runner.Observer interface.
+func (l *live) OnWorkflowEnd(wf *runner.Workflow) {
+	close(l.stop)
+	<-l.done
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.root.status = wf.Status
+	l.root.duration = wf.Duration
+	if wf.Err != nil {
+		// Groups interrupted by the failure.
+		for _, n := range l.groups {
+			if n.status == runner.StatusRunning {
+				n.status = runner.StatusFailed
+				n.duration = time.Since(n.start)
+			}
+		}
+	}
+	l.redraw()
+}
+
+func (l *live) loop(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(liveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			l.lock.Lock()
+			l.tick++
+			l.redraw()
+			l.lock.Unlock()
+		}
+	}
+}
+
+func (l *live) add(parent *liveNode, t *runner.Task, group bool) *liveNode {
+	n := &liveNode{
+		name:   t.Name,
+		desc:   t.Description,
+		group:  group,
+		status: runner.StatusRunning,
+		start:  t.Start,
+	}
+	parent.children = append(parent.children, n)
+	l.tasks[t.ID] = n
+	return n
+}
+
+// parent returns the innermost group the node belongs to.
+func (l *live) parent(node *dag.Node) *liveNode {
+	scope := node.Scope()
+	for i := len(scope) - 1; i >= 0; i-- {
+		if n, ok := l.groups[scope[i]]; ok {
+			return n
+		}
+	}
+	return l.root
+}
+
+// redraw replaces the previous frame with the current one, it must be called with the lock held.
+func (l *live) redraw() {
+	buf := bytes.NewBuffer(nil)
+	if l.lines > 0 {
+		fmt.Fprintf(buf, "\033[%dA", l.lines)
+	}
+	buf.WriteString("\r\033[J")
+
+	lines := l.frame()
+	for _, line := range lines {
+		buf.WriteString(line)
+		buf.WriteRune('\n')
+	}
+	l.lines = len(lines)
+
+	_, _ = buf.WriteTo(l.w)
+}
+
+// frame renders the tree, every line fits the width and the frame fits the height, so the number of lines on the screen is known.
+func (l *live) frame() []string {
+	lines := l.tree(false)
+	if len(lines) > l.height {
+		lines = l.tree(true)
+	}
+	if l.height > 2 && len(lines) > l.height {
+		// The first line shows the status of the whole workflow, the last ones what is going on right now.
+		keep := l.height - 2
+		hidden := l.paint("2", l.fit("", fmt.Sprintf("… %d lines hidden", len(lines)-1-keep)))
+		lines = append([]string{lines[0], hidden}, lines[len(lines)-keep:]...)
+	}
+
+	return lines
+}
+
+// tree renders all the nodes, finished groups are collapsed, in the compact mode finished tasks are collapsed too.
+func (l *live) tree(compact bool) []string {
+	var lines []string
+	var walk func(n *liveNode, depth int)
+	walk = func(n *liveNode, depth int) {
+		indent := strings.Repeat("  ", depth)
+		lines = append(lines, l.line(indent, n))
+
+		switch {
+		case n.group && n.status == runner.StatusOK:
+			// Collapsed.
+		case n.group:
+			var finished int
+			for _, child := range n.children {
+				if compact && child.status == runner.StatusOK {
+					finished++
+				}
+			}
+			if finished > 0 {
+				text := fmt.Sprintf("%d finished", finished)
+				lines = append(lines, indent+"  "+l.paint("92", "✓")+" "+truncate(text, l.width-len(indent)-4))
+			}
+			for _, child := range n.children {
+				if compact && child.status == runner.StatusOK {
+					continue
+				}
+				walk(child, depth+1)
+			}
+		case n.status != runner.StatusOK:
+			for _, text := range n.output {
+				lines = append(lines, l.paint("2", l.fit(indent+"  │ ", text)))
+			}
+		}
+		if n.log != "" {
+			lines = append(lines, l.fit(indent+"  ", "log: "+n.log))
+		}
+		if n.err != nil {
+			lines = append(lines, l.paint("91", l.fit(indent+"  ", "error: "+n.err.Error())))
+		}
+	}
+	if l.root != nil {
+		walk(l.root, 0)
+	}
+
+	return lines
+}
+
+func (l *live) line(indent string, n *liveNode) string {
+	var icon string
+	switch n.status {
+	case runner.StatusOK:
+		icon = l.paint("92", "✓")
+	case runner.StatusFailed:
+		icon = l.paint("91", "✗")
+	default:
+		icon = l.paint("93", spinner[l.tick%len(spinner)])
+	}
+
+	elapsed := n.duration
+	if n.status == runner.StatusRunning {
+		elapsed = time.Since(n.start)
+	}
+	timer := " " + elapsed.Round(100*time.Millisecond).String()
+
+	text := n.name
+	switch {
+	case n.group && n.status == runner.StatusOK:
+		text += fmt.Sprintf(" (%d tasks)", count(n))
+	case !n.group && n.desc != "":
+		text += ": " + n.desc
+	}
+	text = truncate(text, l.width-utf8.RuneCountInString(indent+"  "+timer))
+
+	return indent + icon + " " + text + l.paint("2", timer)
+}
+
+// fit truncates the text so the line does not exceed the width.
+func (l *live) fit(prefix, text string) string {
+	return prefix + truncate(text, l.width-utf8.RuneCountInString(prefix))
+}
+
+// truncate returns the first line of the text, shortened to max characters.
+// Escape sequences are removed and tabs are expanded, so every character takes exactly one column.
+func truncate(text string, max int) string {
+	if i := strings.IndexRune(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	text = printable(text)
+	if max <= 0 {
+		return ""
+	}
+	if runes := []rune(text); len(runes) > max {
+		return string(runes[:max-1]) + "…"
+	}
+	return text
+}
+
+// printable prepares a single line of an output for printing, a carriage return (e.g. of a progress bar)
+// means that only the text after it is visible.
+func printable(text string) string {
+	text = strings.TrimRight(text, "\r")
+	if i := strings.LastIndex(text, "\r"); i >= 0 {
+		text = text[i+1:]
+	}
+	text = draw.StripEscapes(text)
+	if !strings.ContainsRune(text, '\t') {
+		return text
+	}
+
+	var b strings.Builder
+	var col int
+	for _, r := range text {
+		if r == '\t' {
+			spaces := tabWidth - col%tabWidth
+			b.WriteString(strings.Repeat(" ", spaces))
+			col += spaces
+			continue
+		}
+		b.WriteRune(r)
+		col++
+	}
+	return b.String()
+}
+
+func (l *live) paint(code, s string) string {
+	if !l.color {
+		return s
+	}
+	return fmt.Sprintf("\033[%sm%s\033[0m", code, s)
+}
+
+// count returns the number of tasks within the group, including nested ones.
+func count(n *liveNode) int {
+	var c int
+	for _, child := range n.children {
+		if child.group {
+			c += count(child)
+		} else {
+			c++
+		}
+	}
+	return c
+}
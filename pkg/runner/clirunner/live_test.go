@@ -0,0 +1,113 @@
+package clirunner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/runner"
+)
+
+func TestLive(t *testing.T) {
+	lint := rosie.Group("lint")
+	lint.Beginning().Then(rosie.Cmd("vet", "echo", "vet"))
+
+	test := rosie.Group("test")
+	test.Beginning().
+		Then(rosie.Fn("fail", func(_ context.Context, w io.Writer, _ rosie.Resulter) (interface{}, error) {
+			for i := 1; i <= 3; i++ {
+				_, _ = fmt.Fprintf(w, "line %d\n", i)
+			}
+			return nil, errors.New("failure")
+		}))
+
+	g := rosie.Group("build")
+	g.Beginning().
+		Then(rosie.Cmd("echo", "echo", "hello")).
+		Then(lint).
+		Then(test)
+
+	b := bytes.NewBuffer(nil)
+	l := newLive(b, 41, 24, false, 2)
+	if err := runner.New(l).Run(context.Background(), g); err == nil {
+		t.Fatal("error expected")
+	}
+
+	timer := regexp.MustCompile(` [0-9.]+[mµn]?s$`)
+	var got []string
+	for _, line := range l.frame() {
+		got = append(got, timer.ReplaceAllString(line, " T"))
+	}
+	exp := []string{
+		"✗ build T",
+		"  ✓ echo: echo hello T",
+		"  ✓ lint (1 tasks) T",
+		"  ✗ test T",
+		"    ✗ fail T",
+		"      │ line 2",
+		"      │ line 3",
+		"      error: failure",
+	}
+	if strings.Join(got, "\n") != strings.Join(exp, "\n") {
+		t.Errorf("unexpected frame:\n%s", strings.Join(got, "\n"))
+	}
+	if !strings.Contains(b.String(), "\033[8A") {
+		t.Error("previous frame should be replaced")
+	}
+}
+
+func TestLive_height(t *testing.T) {
+	g := rosie.Group("build")
+	g.Beginning().
+		Then(rosie.Cmd("one", "echo", "1")).
+		Then(rosie.Cmd("two", "echo", "2")).
+		Then(rosie.Fn("fail", func(_ context.Context, w io.Writer, _ rosie.Resulter) (interface{}, error) {
+			for i := 1; i <= 5; i++ {
+				_, _ = fmt.Fprintf(w, "line %d\n", i)
+			}
+			return nil, errors.New("failure")
+		}))
+
+	l := newLive(bytes.NewBuffer(nil), 41, 7, false, 5)
+	if err := runner.New(l).Run(context.Background(), g); err == nil {
+		t.Fatal("error expected")
+	}
+
+	timer := regexp.MustCompile(` [0-9.]+[mµn]?s$`)
+	var got []string
+	for _, line := range l.frame() {
+		got = append(got, timer.ReplaceAllString(line, " T"))
+	}
+	exp := []string{
+		"✗ build T",
+		"… 4 lines hidden",
+		"    │ line 3",
+		"    │ line 4",
+		"    │ line 5",
+		"    error: failure",
+	}
+	if strings.Join(got, "\n") != strings.Join(exp, "\n") {
+		t.Errorf("unexpected frame:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("hello world\nsecond", 8); got != "hello w…" {
+		t.Errorf("unexpected result: %s", got)
+	}
+	if got := truncate("hello", 8); got != "hello" {
+		t.Errorf("unexpected result: %s", got)
+	}
+	if got := truncate("\033[32mok\033[0m\tdone", 12); got != "ok      done" {
+		t.Errorf("unexpected result: %q", got)
+	}
+	if got := truncate("10%\r100%\r\n", 8); got != "100%" {
+		t.Errorf("unexpected result: %q", got)
+	}
+}
@@ -0,0 +1,145 @@
+package clirunner
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/travelaudience/rosie/pkg/runner"
+)
+
+const defaultFailureTail = 20
+
+var _ runner.Observer = &taskLogs{}
+
+// taskLogs writes the output of every task into its own file, within a directory created for each run.
+// Files are named after the order of execution and the path of the task, e.g. 002-build_go-build.log.
+type taskLogs struct {
+	dir    string
+	runDir string
+	files  map[string]*os.File
+	bufs   map[string]*bufio.Writer
+	paths  map[string]string
+	err    error
+}
+
+func newTaskLogs(dir string) *taskLogs {
+	return &taskLogs{dir: dir}
+}
+
+// OnWorkflowStart implements runner.Observer interface.
+func (l *taskLogs) OnWorkflowStart(wf *runner.Workflow) {
+	l.files = make(map[string]*os.File)
+	l.bufs = make(map[string]*bufio.Writer)
+	l.paths = make(map[string]string)
+	l.err = nil
+
+	name := wf.Start.Format("20060102-150405")
+	if wf.Name != "" {
+		name += "-" + sanitize(wf.Name)
+	}
+	if l.err = os.MkdirAll(l.dir, 0755); l.err != nil {
+		return
+	}
+	// The random suffix keeps runs started within the same second apart.
+	l.runDir, l.err = ioutil.TempDir(l.dir, name+"-")
+}
+
+// OnTaskStart implements runner.Observer interface.
+func (l *taskLogs) OnTaskStart(t *runner.Task) {
+	if l.err != nil || !t.Executable {
+		return
+	}
+
+	path := filepath.Join(l.runDir, fmt.Sprintf("%03s-%s.log", t.ID, sanitize(t.Path)))
+	/* #nosec */
+	f, err := os.Create(path)
+	if err != nil {
+		l.err = err
+		return
+	}
+	l.files[t.ID] = f
+	l.bufs[t.ID] = bufio.NewWriter(f)
+	l.paths[t.ID] = path
+
+	if t.Description != "" {
+		_, _ = fmt.Fprintf(l.bufs[t.ID], "# %s\n", t.Description)
+	}
+}
+
+// OnOutput implements runner.Observer interface.
+func (l *taskLogs) OnOutput(t *runner.Task, text string) {
+	if w, ok := l.bufs[t.ID]; ok {
+		_, _ = w.WriteString(text + "\n")
+	}
+}
+
+// OnTaskEnd implements runner.Observer interface.
+func (l *taskLogs) OnTaskEnd(t *runner.Task) {
+	f, ok := l.files[t.ID]
+	if !ok {
+		return
+	}
+	if t.Err != nil {
+		_, _ = fmt.Fprintf(l.bufs[t.ID], "# failure with error: %s\n", t.Err)
+	}
+	if err := l.bufs[t.ID].Flush(); err != nil && l.err == nil {
+		l.err = err
+	}
+	if err := f.Close(); err != nil && l.err == nil {
+		l.err = err
+	}
+	delete(l.files, t.ID)
+	delete(l.bufs, t.ID)
+}
+
+// OnWorkflowEnd implements runner.Observer interface.
+func (l *taskLogs) OnWorkflowEnd(*runner.Workflow) {}
+
+// path returns the log file of the task, or an empty string if there is none.
+func (l *taskLogs) path(id string) string {
+	if l == nil {
+		return ""
+	}
+	return l.paths[id]
+}
+
+// sanitize makes the path of a task usable as a file name.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
+
+// tail keeps the last lines written to it.
+type tail struct {
+	max   int
+	lines []string
+}
+
+func (t *tail) add(line string) {
+	t.lines = append(t.lines, line)
+	if len(t.lines) > t.max {
+		t.lines = t.lines[len(t.lines)-t.max:]
+	}
+}
+
+func (t *tail) reset() {
+	t.lines = t.lines[:0]
+}
+
+// failureTail returns the number of output lines printed for a failed task.
+func failureTail(opts VerbosityOpts) int {
+	if opts.FailureTail > 0 {
+		return opts.FailureTail
+	}
+	return defaultFailureTail
+}
@@ -0,0 +1,71 @@
+package clirunner
+
+import (
+	"fmt"
+
+	"github.com/travelaudience/rosie/pkg/runner"
+)
+
+var _ runner.Observer = &plainPrinter{}
+
+// plainPrinter writes every line prefixed with the path of the task it belongs to, so the output can be grepped.
+type plainPrinter struct {
+	drawer  Drawer
+	verbose VerbosityOpts
+	logs    *taskLogs
+	tail    tail
+}
+
+// OnWorkflowStart implements runner.Observer interface.
+func (p *plainPrinter) OnWorkflowStart(*runner.Workflow) {}
+
+// OnTaskStart implements runner.Observer interface.
+func (p *plainPrinter) OnTaskStart(t *runner.Task) {
+	p.tail.reset()
+	if !p.verbose.Task || !t.Executable {
+		return
+	}
+	line := "› " + t.Name
+	if t.Description != "" {
+		line += ": " + t.Description
+	}
+	p.line(t, line)
+}
+
+// OnOutput implements runner.Observer interface.
+func (p *plainPrinter) OnOutput(t *runner.Task, text string) {
+	p.tail.add(text)
+	if !p.verbose.Output {
+		return
+	}
+	p.line(t, text)
+}
+
+// OnTaskEnd implements runner.Observer interface.
+func (p *plainPrinter) OnTaskEnd(t *runner.Task) {
+	if !t.Executable {
+		return
+	}
+	switch {
+	case t.Err != nil:
+		if !p.verbose.Output {
+			for _, line := range p.tail.lines {
+				p.line(t, line)
+			}
+		}
+		if path := p.logs.path(t.ID); path != "" {
+			p.line(t, "log: "+path)
+		}
+		p.line(t, fmt.Sprintf("✗ failure with error: %s", t.Err))
+	case p.verbose.Task:
+		p.line(t, fmt.Sprintf("✓ ok ⏱  %s", t.Duration))
+	}
+}
+
+// OnWorkflowEnd implements runner.Observer interface.
+func (p *plainPrinter) OnWorkflowEnd(*runner.Workflow) {}
+
+func (p *plainPrinter) line(t *runner.Task, text string) {
+	p.drawer.NewLine(fmt.Sprintf("[%s] %s", t.Path, text))
+	p.drawer.EndLine()
+}
@@ -1,7 +1,6 @@
 package clirunner
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -11,11 +10,12 @@ import (
 	"github.com/travelaudience/rosie"
 	"github.com/travelaudience/rosie/internal/draw"
 	"github.com/travelaudience/rosie/pkg/dag"
+	"github.com/travelaudience/rosie/pkg/runner"
 )
 
-type Iterator interface {
-	Iter() (*rosie.Iterator, error)
-}
+type Iterator = runner.Iterator
+
+var _ runner.Observer = &printer{}
 
 type Drawer interface {
 	NewEntry(length int, text string)
@@ -29,69 +29,110 @@ type Drawer interface {
 type VerbosityOpts struct {
 	Output bool
 	Task   bool
+	// Plain prints every line prefixed with the path of the task, e.g. [build/go-build] output, instead of drawing boxes.
+	Plain bool
+	// Live redraws the tree of groups and running tasks in place, it requires a terminal.
+	// Boxes (or plain lines) are printed instead if the output is not a terminal.
+	Live bool
+	// Tail is the number of the most recent output lines shown for a running task in the live mode, 5 if zero.
+	Tail int
+	// LogDir enables writing the output of every task into its own file, within a directory created for each run.
+	LogDir string
+	// FailureTail is the number of the last output lines printed for a failed task if Output is disabled, 20 if zero.
+	FailureTail int
+	// Summary prints a table of all tasks with their statuses and durations once the run is completed.
+	Summary bool
 }
 
 type Runner struct {
-	opts VerbosityOpts
-	p    *printer
+	opts     VerbosityOpts
+	drawer   Drawer
+	logs     *taskLogs
+	reporter *reporter
+	engine   *runner.Engine
 }
 
-func New(draw Drawer, opts VerbosityOpts) *Runner {
-	return &Runner{
-		opts: opts,
-		p: &printer{
-			drawer:  draw,
+// New creates a runner that draws the progress using the given drawer.
+// In the plain and live modes draw.Drawer is replaced by a drawer writing to the same writer.
+func New(d Drawer, opts VerbosityOpts) *Runner {
+	var logs *taskLogs
+	if opts.LogDir != "" {
+		logs = newTaskLogs(opts.LogDir)
+	}
+
+	var obs runner.Observer
+	dd, isDrawer := d.(*draw.Drawer)
+	switch {
+	case opts.Live && isDrawer && draw.IsTerminal(dd.W):
+		l := newLive(dd.W, dd.Width(), draw.DetectHeight(dd.W), !dd.NoColor, opts.Tail)
+		l.logs = logs
+		obs = l
+	case opts.Plain:
+		if isDrawer {
+			d = &draw.Lines{W: dd.W, NoColor: dd.NoColor}
+		}
+		obs = &plainPrinter{
+			drawer:  d,
+			verbose: opts,
+			logs:    logs,
+			tail:    tail{max: failureTail(opts)},
+		}
+	default:
+		obs = &printer{
+			drawer:  d,
 			depth:   0,
 			verbose: opts,
-		},
+			logs:    logs,
+			tail:    tail{max: failureTail(opts)},
+		}
 	}
-}
 
-func (r *Runner) Run(ctx context.Context, prov Iterator) error {
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
-	iter, err := prov.Iter()
-	if err != nil {
-		return err
+	r := &Runner{
+		opts:     opts,
+		drawer:   d,
+		logs:     logs,
+		reporter: &reporter{logs: logs},
+		engine:   runner.New(),
 	}
+	// Logs go first, so they are complete once a failure is printed.
+	if logs != nil {
+		r.engine.Observe(logs)
+	}
+	r.engine.Observe(r.reporter, obs)
+	return r
+}
 
-	for {
-		tsk, ok := iter.Next()
-		if !ok {
-			break
-		}
-
-		r.p.next()
-
-		if rnr, ok := tsk.(rosie.Executor); ok {
-			out, err := rnr.Exec(ctx)
-			r.p.logBefore(tsk)
-
-			if err != nil {
-				r.p.logAfter(tsk)
-				return err
-			}
+// Observe registers additional observers, they are notified after the output is printed.
+func (r *Runner) Observe(observers ...runner.Observer) {
+	r.engine.Observe(observers...)
+}
 
-			if err := r.p.drain(out); err != nil {
-				r.p.logAfter(tsk)
-				return err
-			}
-			r.p.logAfter(tsk)
-		} else {
-			r.p.logBefore(tsk)
-		}
+// Run executes the workflow, it returns the report of the run along with the error the run failed with.
+func (r *Runner) Run(ctx context.Context, prov Iterator) (*RunReport, error) {
+	err := r.engine.Run(ctx, prov)
+	if r.opts.Summary {
+		printSummary(r.drawer, r.reporter.report)
 	}
 
-	r.p.drawer.EndEntry(0)
+	if r.logs != nil && r.logs.err != nil && err == nil {
+		err = fmt.Errorf("writing logs: %s", r.logs.err)
+	}
+	return r.reporter.report, err
+}
 
-	return nil
+// LogDir returns the directory the logs of the last run were written into, it is empty if LogDir option is not set.
+func (r *Runner) LogDir() string {
+	if r.logs == nil {
+		return ""
+	}
+	return r.logs.runDir
 }
+
+// Run executes the workflow and prints the progress to the writer.
+// The output fits the width of the terminal, colors are disabled if the writer is not a terminal or NO_COLOR is set.
 func Run(ctx context.Context, w io.Writer, prov Iterator, ver VerbosityOpts) error {
-	r := New(&draw.Drawer{
-		W: w,
-	}, ver)
-	return r.Run(ctx, prov)
+	_, err := New(draw.New(w), ver).Run(ctx, prov)
+	return err
 }
 
 type printer struct {
@@ -99,8 +140,10 @@ type printer struct {
 	depth                   int
 	verbose                 VerbosityOpts
 	start                   time.Time
-	combout                 *bytes.Buffer
+	logs                    *taskLogs
+	tail                    tail
 	openSection, openHeader bool
+	outputStarted           bool
 	previous                rosie.Joint
 }
 
@@ -109,7 +152,7 @@ func (p *printer) logBefore(tsk rosie.Joint) {
 		p.drawer.NewSection()
 		text := "\033[92m\u2713\033[0m ok"
 		p.drawer.NewLine(text)
-		p.drawer.NewColumn(draw.Width-2-utf8.RuneCountInString(text), fmt.Sprintf("\u23F1  %s", time.Since(p.start).String()))
+		p.drawer.NewColumn(p.width()-2-utf8.RuneCountInString(text), fmt.Sprintf("\u23F1  %s", time.Since(p.start).String()))
 		p.drawer.EndLine()
 		p.start = time.Time{}
 	}
@@ -164,43 +207,77 @@ func (p *printer) logBefore(tsk rosie.Joint) {
 	}
 }
 
-func (p *printer) logAfter(tsk rosie.Joint) {
+func (p *printer) logAfter(t *runner.Task) {
 	defer func() {
-		p.previous = tsk
+		p.previous = t.Joint
 	}()
-	if rnr, ok := tsk.(rosie.Executor); ok {
-		if rnr.Result().Err() != nil {
-			p.drawer.NewSection()
-			p.drawer.NewLine(fmt.Sprintf("\u23F1  %s", time.Since(p.start).String()))
-			p.drawer.NewColumn(draw.Width, fmt.Sprintf("\033[91m\u2717\033[0m failure with error: %s", rnr.Result().Err()))
+	if t.Err != nil {
+		p.drawer.NewSection()
+		if !p.verbose.Output && len(p.tail.lines) > 0 {
+			p.drawer.NewLine(fmt.Sprintf("  last %d lines of output:", len(p.tail.lines)))
 			p.drawer.EndLine()
-			return
-		}
-	}
-}
-
-func (p *printer) drain(in <-chan rosie.Piece) error {
-	start := false
-	for piece := range in {
-		if piece.Err != nil {
-			return piece.Err
-		}
-		if p.verbose.Output {
-			if !start {
-				p.drawer.NewLine("  output:")
+			for _, line := range p.tail.lines {
+				p.drawer.NewLine("  " + gray(line))
 				p.drawer.EndLine()
-				start = true
 			}
-			p.drawer.NewLine("  " + gray(piece.Text))
+		}
+		if path := p.logs.path(t.ID); path != "" {
+			p.drawer.NewLine(fmt.Sprintf("  log: %s", path))
 			p.drawer.EndLine()
 		}
+		p.drawer.NewLine(fmt.Sprintf("\u23F1  %s", time.Since(p.start).String()))
+		p.drawer.NewColumn(p.width(), fmt.Sprintf("\033[91m\u2717\033[0m failure with error: %s", t.Err))
+		p.drawer.EndLine()
+	}
+}
+
+// OnWorkflowStart implements runner.Observer interface.
+func (p *printer) OnWorkflowStart(*runner.Workflow) {}
+
+// OnTaskStart implements runner.Observer interface.
+func (p *printer) OnTaskStart(t *runner.Task) {
+	p.next()
+	p.logBefore(t.Joint)
+}
+
+// OnOutput implements runner.Observer interface.
+func (p *printer) OnOutput(_ *runner.Task, text string) {
+	p.tail.add(text)
+	if !p.verbose.Output {
+		return
+	}
+	if !p.outputStarted {
+		p.drawer.NewLine("  output:")
+		p.drawer.EndLine()
+		p.outputStarted = true
 	}
+	p.drawer.NewLine("  " + gray(text))
+	p.drawer.EndLine()
+}
 
-	return nil
+// OnTaskEnd implements runner.Observer interface.
+func (p *printer) OnTaskEnd(t *runner.Task) {
+	if t.Executable {
+		p.logAfter(t)
+	}
+}
+
+// OnWorkflowEnd implements runner.Observer interface.
+func (p *printer) OnWorkflowEnd(*runner.Workflow) {
+	p.drawer.EndEntry(0)
+}
+
+// width returns the number of columns supported by the drawer.
+func (p *printer) width() int {
+	if w, ok := p.drawer.(interface{ Width() int }); ok {
+		return w.Width()
+	}
+	return draw.Width
 }
 
 func (p *printer) next() {
-	p.combout = &bytes.Buffer{}
+	p.tail.reset()
+	p.outputStarted = false
 }
 
 func gray(s string) string {
@@ -211,6 +288,10 @@ func blue(s string) string {
 	return fmt.Sprintf("\033[34m%s\033[0m", s)
 }
 
+func red(s string) string {
+	return fmt.Sprintf("\033[91m%s\033[0m", s)
+}
+
 func lightYellow(s string) string {
 	return fmt.Sprintf("\033[93m%s\033[0m", s)
 }
@@ -0,0 +1,157 @@
+package clirunner_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/internal/draw"
+	"github.com/travelaudience/rosie/pkg/runner"
+	"github.com/travelaudience/rosie/pkg/runner/clirunner"
+)
+
+func TestRunner_plain(t *testing.T) {
+	inner := rosie.Group("test")
+	inner.Beginning().
+		Then(rosie.Fn("fail", func(_ context.Context, w io.Writer, _ rosie.Resulter) (interface{}, error) {
+			_, _ = io.WriteString(w, "failing\n")
+			return nil, errors.New("failure")
+		}))
+
+	g := rosie.Group("build")
+	g.Beginning().
+		Then(rosie.Cmd("echo", "echo", "hello")).
+		Then(inner)
+
+	b := bytes.NewBuffer(nil)
+	r := clirunner.New(&draw.Drawer{W: b}, clirunner.VerbosityOpts{Output: true, Plain: true})
+	if _, err := r.Run(context.Background(), g); err == nil {
+		t.Fatal("error expected")
+	}
+
+	exp := []string{
+		"[build/echo] hello",
+		"[build/test/fail] failing",
+		"[build/test/fail] ✗ failure with error: failure",
+	}
+	if got := strings.Split(strings.TrimSpace(b.String()), "\n"); strings.Join(got, "\n") != strings.Join(exp, "\n") {
+		t.Errorf("unexpected output:\n%s", b.String())
+	}
+}
+
+func TestRunner_logs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clirunner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	g := rosie.Group("build")
+	g.Beginning().
+		Then(rosie.Cmd("echo", "echo", "hello")).
+		Then(rosie.Fn("fail", func(_ context.Context, w io.Writer, _ rosie.Resulter) (interface{}, error) {
+			for i := 1; i <= 30; i++ {
+				_, _ = fmt.Fprintf(w, "line %d\n", i)
+			}
+			return nil, errors.New("failure")
+		}))
+
+	for _, plain := range []bool{true, false} {
+		b := bytes.NewBuffer(nil)
+		r := clirunner.New(&draw.Drawer{W: b, NoColor: true}, clirunner.VerbosityOpts{Plain: plain, LogDir: dir, FailureTail: 5})
+		if _, err := r.Run(context.Background(), g.Clone()); err == nil {
+			t.Fatal("error expected")
+		}
+
+		out := b.String()
+		if strings.Contains(out, "line 25") || !strings.Contains(out, "line 26") || !strings.Contains(out, "line 30") {
+			t.Errorf("expected the last 5 lines of output:\n%s", out)
+		}
+		if strings.Contains(out, "hello") {
+			t.Errorf("output of successful tasks should not be printed:\n%s", out)
+		}
+
+		path := filepath.Join(r.LogDir(), "003-build_fail.log")
+		if !strings.Contains(out, "log: "+path) {
+			t.Errorf("expected path of the log:\n%s", out)
+		}
+		log, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(log), "line 1\n") || !strings.HasSuffix(string(log), "line 30\n# failure with error: failure\n") {
+			t.Errorf("unexpected log:\n%s", log)
+		}
+		log, err = ioutil.ReadFile(filepath.Join(r.LogDir(), "002-build_echo.log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(log) != "# echo hello\nhello\n" {
+			t.Errorf("unexpected log:\n%s", log)
+		}
+	}
+}
+
+func TestRunner_summary(t *testing.T) {
+	deploy := rosie.Group("deploy")
+	deploy.Beginning().Then(rosie.Cmd("push", "echo", "push"))
+
+	g := rosie.Group("build")
+	g.Beginning().
+		Then(rosie.Cmd("echo", "echo", "hello")).
+		Then(rosie.Fn("fail", func(context.Context, io.Writer, rosie.Resulter) (interface{}, error) {
+			return nil, errors.New("failure")
+		})).
+		Then(rosie.Cmd("never", "echo", "never")).
+		Then(deploy)
+
+	b := bytes.NewBuffer(nil)
+	r := clirunner.New(&draw.Drawer{W: b, NoColor: true}, clirunner.VerbosityOpts{Plain: true, Summary: true})
+	report, err := r.Run(context.Background(), g)
+	if err == nil {
+		t.Fatal("error expected")
+	}
+
+	if report.Status != runner.StatusFailed || report.Err != err || report.Name != "build" {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	var got []string
+	for _, tsk := range report.Tasks {
+		got = append(got, fmt.Sprintf("%s %s", tsk.Path, tsk.Status))
+	}
+	exp := []string{
+		"build/echo ok",
+		"build/fail failed",
+		"build/never skipped",
+		"build/deploy/push skipped",
+	}
+	if strings.Join(got, "\n") != strings.Join(exp, "\n") {
+		t.Errorf("unexpected tasks:\n%s", strings.Join(got, "\n"))
+	}
+	if report.Count(runner.StatusSkipped) != 2 || len(report.Failed()) != 1 {
+		t.Errorf("unexpected counts: %+v", report)
+	}
+	if s := report.Slowest(1); len(s) != 1 || s[0].Status == runner.StatusSkipped {
+		t.Errorf("unexpected slowest tasks: %+v", s)
+	}
+
+	out := b.String()
+	for _, line := range []string{
+		"summary",
+		"\u2717 failed   build/fail",
+		"- skipped  build/deploy/push",
+		"4 tasks: 1 ok, 1 failed, 2 skipped in ",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("summary should contain %q:\n%s", line, out)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package clirunner
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/travelaudience/rosie/pkg/runner"
+)
+
+// slowest is the number of tasks highlighted in the summary.
+const slowest = 3
+
+// RunReport describes the outcome of a run.
+type RunReport struct {
+	Name     string
+	Status   runner.Status
+	Duration time.Duration
+	Err      error
+	// Tasks are listed in the order of execution, followed by the ones that were skipped.
+	// There is no status for cached tasks, results of tasks are never cached.
+	Tasks []TaskReport
+	// LogDir is the directory the output of tasks was written into, see VerbosityOpts.LogDir.
+	LogDir string
+}
+
+// TaskReport describes the outcome of a single task.
+type TaskReport struct {
+	Name        string
+	Path        string
+	Description string
+	Status      runner.Status
+	Duration    time.Duration
+	Err         error
+	// Log is the file the output of the task was written into, if any.
+	Log string
+}
+
+// Count returns the number of tasks with the given status.
+func (r *RunReport) Count(status runner.Status) int {
+	var c int
+	for _, t := range r.Tasks {
+		if t.Status == status {
+			c++
+		}
+	}
+	return c
+}
+
+// Failed returns the tasks that failed.
+func (r *RunReport) Failed() []TaskReport {
+	var res []TaskReport
+	for _, t := range r.Tasks {
+		if t.Status == runner.StatusFailed {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// Slowest returns up to n tasks that took the most time, the slowest first.
+func (r *RunReport) Slowest(n int) []TaskReport {
+	res := make([]TaskReport, 0, len(r.Tasks))
+	for _, t := range r.Tasks {
+		if t.Status != runner.StatusSkipped {
+			res = append(res, t)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Duration > res[j].Duration
+	})
+	if len(res) > n {
+		res = res[:n]
+	}
+	return res
+}
+
+var _ runner.Observer = &reporter{}
+
+// reporter builds the report of a run.
+type reporter struct {
+	report *RunReport
+	logs   *taskLogs
+}
+
+// OnWorkflowStart implements runner.Observer interface.
+func (r *reporter) OnWorkflowStart(wf *runner.Workflow) {
+	r.report = &RunReport{Name: wf.Name, Status: wf.Status}
+}
+
+// OnTaskStart implements runner.Observer interface.
+func (r *reporter) OnTaskStart(*runner.Task) {}
+
+// OnOutput implements runner.Observer interface.
+func (r *reporter) OnOutput(*runner.Task, string) {}
+
+// OnTaskEnd implements runner.Observer interface.
+func (r *reporter) OnTaskEnd(t *runner.Task) {
+	if !t.Reportable() {
+		return
+	}
+	r.report.Tasks = append(r.report.Tasks, TaskReport{
+		Name:        t.Name,
+		Path:        t.Path,
+		Description: t.Description,
+		Status:      t.Status,
+		Duration:    t.Duration,
+		Err:         t.Err,
+		Log:         r.logs.path(t.ID),
+	})
+}
+
+// OnWorkflowEnd implements runner.Observer interface.
+func (r *reporter) OnWorkflowEnd(wf *runner.Workflow) {
+	r.report.Status = wf.Status
+	r.report.Duration = wf.Duration
+	r.report.Err = wf.Err
+	for _, t := range wf.Skipped {
+		r.report.Tasks = append(r.report.Tasks, TaskReport{
+			Name:   t.Name,
+			Path:   t.Path,
+			Status: t.Status,
+		})
+	}
+	if r.logs != nil {
+		r.report.LogDir = r.logs.runDir
+	}
+}
+
+// printSummary prints a table of all tasks, with the slowest ones highlighted, followed by the totals.
+func printSummary(d Drawer, report *RunReport) {
+	// Indices of tasks that ran, the slowest first.
+	order := make([]int, 0, len(report.Tasks))
+	for i, t := range report.Tasks {
+		if t.Status != runner.StatusSkipped {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return report.Tasks[order[i]].Duration > report.Tasks[order[j]].Duration
+	})
+	highlighted := make(map[int]bool)
+	if len(order) > slowest {
+		for _, i := range order[:slowest] {
+			highlighted[i] = true
+		}
+	}
+
+	buf := bytes.NewBuffer(nil)
+	tw := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+	for i, t := range report.Tasks {
+		var icon, duration, note string
+		switch t.Status {
+		case runner.StatusOK:
+			icon = "\u2713"
+		case runner.StatusFailed:
+			icon = "\u2717"
+		default:
+			icon = "-"
+		}
+		if t.Status != runner.StatusSkipped {
+			duration = t.Duration.Round(time.Millisecond).String()
+		}
+		if highlighted[i] {
+			note = "slowest"
+		}
+		_, _ = fmt.Fprintf(tw, "%s %s\t%s\t%s\t%s\n", icon, t.Status, t.Path, duration, note)
+	}
+	_ = tw.Flush()
+
+	d.NewEntry(0, fmt.Sprintf("\u2022 %s", lightYellow("summary")))
+	if len(report.Tasks) > 0 {
+		d.NewSection()
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		for i, line := range lines {
+			line = strings.TrimRight(line, " ")
+			switch {
+			case highlighted[i]:
+				line = lightYellow(line)
+			case report.Tasks[i].Status == runner.StatusFailed:
+				line = red(line)
+			case report.Tasks[i].Status == runner.StatusSkipped:
+				line = gray(line)
+			}
+			d.NewLine(line)
+			d.EndLine()
+		}
+	}
+	d.NewSection()
+	d.NewLine(fmt.Sprintf("%d tasks: %d ok, %d failed, %d skipped in %s",
+		len(report.Tasks),
+		report.Count(runner.StatusOK),
+		report.Count(runner.StatusFailed),
+		report.Count(runner.StatusSkipped),
+		report.Duration.Round(time.Millisecond),
+	))
+	d.EndLine()
+	d.EndEntry(0)
+}
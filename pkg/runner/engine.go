@@ -0,0 +1,301 @@
+// Package runner provides the execution engine shared by all runners.
+// The engine iterates over a workflow, executes its tasks one by one and notifies observers about the progress.
+// Observers are the extension point for presentation, metrics, logging, notifications and so on.
+package runner
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/dag"
+)
+
+// Iterator is implemented by workflows, e.g. rosie.GroupTask.
+// If it implements Name() string as well, the name is going to be passed to observers.
+type Iterator interface {
+	Iter() (*rosie.Iterator, error)
+}
+
+// Observer is notified by the engine about the progress of a run.
+// All methods are called sequentially, from the goroutine that runs the engine.
+type Observer interface {
+	OnWorkflowStart(wf *Workflow)
+	// OnTaskStart is called once a task is started, which means the description (e.g. a command) is already rendered.
+	OnTaskStart(t *Task)
+	OnOutput(t *Task, text string)
+	OnTaskEnd(t *Task)
+	OnWorkflowEnd(wf *Workflow)
+}
+
+// ContextObserver can be implemented by observers that need to pass values down to tasks, e.g. a trace context.
+type ContextObserver interface {
+	// TaskContext is called for every task before it is started, the returned context is passed to the task.
+	TaskContext(ctx context.Context, t *Task) context.Context
+}
+
+// Status of a workflow or a task.
+type Status string
+
+const (
+	StatusRunning Status = "running"
+	StatusOK      Status = "ok"
+	StatusFailed  Status = "failed"
+	// StatusSkipped describes tasks that were not reached, see Workflow.Skipped.
+	StatusSkipped Status = "skipped"
+)
+
+// Workflow describes a single run.
+type Workflow struct {
+	Name     string
+	Start    time.Time
+	Duration time.Duration
+	Status   Status
+	// Err is set once the workflow failed.
+	Err error
+	// Skipped lists executable tasks that were not reached, it is set once the workflow ends.
+	// The workflow has to expose its graph (e.g. rosie.GroupTask), tasks generated during the run (e.g. by ForEach) are unknown unless they were reached.
+	Skipped []*Task
+}
+
+// Task describes a single task (or a group marker, e.g. the beginning of a group) that is being processed.
+type Task struct {
+	// ID is unique within a single run.
+	ID    string
+	Joint rosie.Joint
+	Name  string
+	// Path consists of names of all groups the task belongs to, and its own name, e.g. build/go-build.
+	Path string
+	// Description is rendered during the execution, e.g. a command with all the arguments.
+	Description string
+	Type        dag.Type
+	// Executable is false for joints that only mark the structure, e.g. beginnings and ends of groups.
+	Executable bool
+	Start      time.Time
+	Duration   time.Duration
+	Status     Status
+	// Err is set once the task failed.
+	Err error
+}
+
+// Reportable tells whether the task belongs in reports of a run, e.g. a summary.
+// Structural tasks (e.g. the beginning of a ForEach group) are reportable only if they failed.
+func (t *Task) Reportable() bool {
+	return t.Executable && (t.Type == dag.TypeMiddle || t.Err != nil)
+}
+
+// Engine executes workflows, it can be reused but it is not safe for concurrent use.
+type Engine struct {
+	observers []Observer
+	ids       map[*dag.Node]string
+	keepGoing bool
+}
+
+func New(observers ...Observer) *Engine {
+	return &Engine{
+		observers: observers,
+	}
+}
+
+// Observe registers additional observers.
+func (e *Engine) Observe(observers ...Observer) {
+	e.observers = append(e.observers, observers...)
+}
+
+// ContinueOnFailure makes the engine go on after a task failed, tasks that depend on the failed one are not executed though.
+// Observers are notified about every failure, Run returns the first one.
+func (e *Engine) ContinueOnFailure(enabled bool) {
+	e.keepGoing = enabled
+}
+
+// Run executes the workflow, it stops at the first failure and returns the error (see ContinueOnFailure).
+// Workflows that can be validated (e.g. rosie.GroupTask) are validated first, nothing is executed if they are not well-formed.
+func (e *Engine) Run(ctx context.Context, it Iterator) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	e.ids = make(map[*dag.Node]string)
+
+	wf := &Workflow{
+		Start:  time.Now(),
+		Status: StatusRunning,
+	}
+	if n, ok := it.(interface{ Name() string }); ok {
+		wf.Name = n.Name()
+	}
+	for _, o := range e.observers {
+		o.OnWorkflowStart(wf)
+	}
+
+	err := e.run(ctx, it)
+
+	wf.Duration = time.Since(wf.Start)
+	wf.Skipped = e.skipped(it)
+	wf.Status = StatusOK
+	if err != nil {
+		wf.Status = StatusFailed
+		wf.Err = err
+	}
+	for _, o := range e.observers {
+		o.OnWorkflowEnd(wf)
+	}
+
+	return err
+}
+
+func (e *Engine) run(ctx context.Context, it Iterator) error {
+	if v, ok := it.(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+
+	iter, err := it.Iter()
+	if err != nil {
+		return err
+	}
+
+	var first error
+	for {
+		tsk, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if tsk.Node().Type() == dag.TypeHidden {
+			continue
+		}
+
+		if err := e.process(ctx, tsk); err != nil {
+			if !e.keepGoing {
+				return err
+			}
+			if first == nil {
+				first = err
+			}
+		}
+	}
+
+	// Once a task failed, the iteration reports tasks that depend on it as unreachable, the failure itself is more relevant.
+	if first != nil {
+		return first
+	}
+	return iter.Err()
+}
+
+func (e *Engine) process(ctx context.Context, tsk rosie.Joint) error {
+	t := e.task(tsk)
+	for _, o := range e.observers {
+		if co, ok := o.(ContextObserver); ok {
+			ctx = co.TaskContext(ctx, t)
+		}
+	}
+
+	rnr, ok := tsk.(rosie.Executor)
+	if !ok {
+		e.start(t)
+		e.end(t, nil)
+		return nil
+	}
+
+	out, err := rnr.Exec(ctx)
+	e.start(t)
+	if err == nil {
+		err = e.drain(t, out)
+	}
+	e.end(t, err)
+
+	return err
+}
+
+// drain forwards the output of a task, it returns the first error received but consumes everything.
+func (e *Engine) drain(t *Task, in <-chan rosie.Piece) error {
+	var err error
+	for piece := range in {
+		if piece.Err != nil {
+			if err == nil {
+				err = piece.Err
+			}
+			continue
+		}
+		for _, o := range e.observers {
+			o.OnOutput(t, piece.Text)
+		}
+	}
+
+	return err
+}
+
+// skipped returns executable tasks of the workflow that were not processed.
+func (e *Engine) skipped(it Iterator) []*Task {
+	g, ok := it.(interface{ Node() *dag.Node })
+	if !ok {
+		return nil
+	}
+	sorted, err := dag.TopologicalSort(g.Node())
+	if err != nil {
+		return nil
+	}
+
+	var res []*Task
+	for _, n := range sorted {
+		if _, ok := e.ids[n]; ok || n.Type() != dag.TypeMiddle {
+			continue
+		}
+		tsk, ok := n.Data.(rosie.Joint)
+		if !ok {
+			continue
+		}
+		if _, ok := tsk.(rosie.Executor); !ok {
+			continue
+		}
+		t := e.task(tsk)
+		t.Start = time.Time{}
+		t.Status = StatusSkipped
+		res = append(res, t)
+	}
+	return res
+}
+
+func (e *Engine) task(tsk rosie.Joint) *Task {
+	node := tsk.Node()
+	id, ok := e.ids[node]
+	if !ok {
+		id = strconv.Itoa(len(e.ids) + 1)
+		e.ids[node] = id
+	}
+
+	_, executable := tsk.(rosie.Executor)
+
+	return &Task{
+		ID:         id,
+		Joint:      tsk,
+		Name:       tsk.Name(),
+		Path:       node.Path(),
+		Type:       node.Type(),
+		Executable: executable,
+		Start:      time.Now(),
+		Status:     StatusRunning,
+	}
+}
+
+func (e *Engine) start(t *Task) {
+	if desc, ok := t.Joint.(interface{ Desc() string }); ok {
+		t.Description = desc.Desc()
+	}
+	for _, o := range e.observers {
+		o.OnTaskStart(t)
+	}
+}
+
+func (e *Engine) end(t *Task, err error) {
+	t.Duration = time.Since(t.Start)
+	t.Status = StatusOK
+	if err != nil {
+		t.Status = StatusFailed
+		t.Err = err
+	}
+	for _, o := range e.observers {
+		o.OnTaskEnd(t)
+	}
+}
@@ -0,0 +1,141 @@
+package runner_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/runner"
+)
+
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) OnWorkflowStart(wf *runner.Workflow) {
+	r.events = append(r.events, "workflow-start "+wf.Name)
+}
+
+func (r *recorder) OnTaskStart(t *runner.Task) {
+	r.events = append(r.events, fmt.Sprintf("task-start %s %s %s", t.ID, t.Path, t.Description))
+}
+
+func (r *recorder) OnOutput(t *runner.Task, text string) {
+	r.events = append(r.events, fmt.Sprintf("output %s %s", t.ID, text))
+}
+
+func (r *recorder) OnTaskEnd(t *runner.Task) {
+	r.events = append(r.events, fmt.Sprintf("task-end %s %s %v", t.ID, t.Status, t.Err))
+}
+
+func (r *recorder) OnWorkflowEnd(wf *runner.Workflow) {
+	r.events = append(r.events, fmt.Sprintf("workflow-end %s %s %v", wf.Name, wf.Status, wf.Err))
+	for _, t := range wf.Skipped {
+		r.events = append(r.events, fmt.Sprintf("skipped %s %s", t.Path, t.Status))
+	}
+}
+
+func TestEngine_Run(t *testing.T) {
+	g := rosie.Group("build")
+	g.Beginning().
+		Then(rosie.Cmd("echo", "echo", "hello")).
+		Then(rosie.Fn("fail", func(_ context.Context, w io.Writer, _ rosie.Resulter) (interface{}, error) {
+			_, _ = fmt.Fprintln(w, "failing")
+			return nil, errors.New("failure")
+		})).
+		Then(rosie.Fn("never", func(_ context.Context, _ io.Writer, _ rosie.Resulter) (interface{}, error) {
+			t.Error("should not be executed")
+			return nil, nil
+		}))
+
+	rec := &recorder{}
+	err := runner.New(rec).Run(context.Background(), g)
+	if err == nil || err.Error() != "failure" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := []string{
+		"workflow-start build",
+		"task-start 1 build ",
+		"task-end 1 ok <nil>",
+		"task-start 2 build/echo echo hello",
+		"output 2 hello",
+		"task-end 2 ok <nil>",
+		"task-start 3 build/fail ",
+		"output 3 failing",
+		"task-end 3 failed failure",
+		"workflow-end build failed failure",
+		"skipped build/never skipped",
+	}
+	if got := strings.Join(rec.events, "\n"); got != strings.Join(exp, "\n") {
+		t.Errorf("wrong events, expected:\n%s\nbut got:\n%s", strings.Join(exp, "\n"), got)
+	}
+}
+
+func TestEngine_Run_invalid(t *testing.T) {
+	lint := rosie.Group("lint")
+	lint.Beginning().
+		Then(rosie.Cmd("vet", "go", "vet", "./..."))
+
+	first := rosie.Group("first")
+	first.Beginning().
+		Then(lint)
+	second := rosie.Group("second")
+	second.Beginning().
+		Then(lint)
+
+	rec := &recorder{}
+	err := runner.New(rec).Run(context.Background(), first)
+	if err == nil || !strings.Contains(err.Error(), "attached to multiple graphs") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := []string{
+		"workflow-start first",
+		"workflow-end first failed " + err.Error(),
+		"skipped first/lint/vet skipped",
+	}
+	if got := strings.Join(rec.events, "\n"); got != strings.Join(exp, "\n") {
+		t.Errorf("wrong events, expected:\n%s\nbut got:\n%s", strings.Join(exp, "\n"), got)
+	}
+}
+
+func TestEngine_ContinueOnFailure(t *testing.T) {
+	g := rosie.Group("build")
+	g.Beginning().
+		Then(rosie.Fn("items", func(_ context.Context, _ io.Writer, _ rosie.Resulter) (interface{}, error) {
+			return []string{"fail", "echo"}, nil
+		})).
+		Then(rosie.ForEach("each", func(string) rosie.Attacher {
+			return rosie.Fn("item", func(_ context.Context, w io.Writer, res rosie.Resulter) (interface{}, error) {
+				if res.Result().Value() == "fail" {
+					return nil, errors.New("failure")
+				}
+				_, _ = fmt.Fprintln(w, "hello")
+				return nil, nil
+			})
+		})).
+		Then(rosie.Fn("never", func(_ context.Context, _ io.Writer, _ rosie.Resulter) (interface{}, error) {
+			t.Error("should not be executed")
+			return nil, nil
+		}))
+
+	rec := &recorder{}
+	e := runner.New(rec)
+	e.ContinueOnFailure(true)
+	err := e.Run(context.Background(), g)
+	if err == nil || err.Error() != "failure" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events := strings.Join(rec.events, "\n")
+	for _, exp := range []string{"failed failure", "hello"} {
+		if !strings.Contains(events, exp) {
+			t.Errorf("missing %q in events:\n%s", exp, events)
+		}
+	}
+}
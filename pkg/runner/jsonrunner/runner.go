@@ -0,0 +1,152 @@
+// Package jsonrunner executes workflows and reports the progress as a stream of newline-delimited JSON events,
+// so it can be consumed by CI systems and dashboards.
+package jsonrunner
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/runner"
+)
+
+type Workflow interface {
+	Name() string
+	Iter() (*rosie.Iterator, error)
+}
+
+type EventType string
+
+const (
+	EventWorkflowStart EventType = "workflow-start"
+	EventTaskStart     EventType = "task-start"
+	EventOutput        EventType = "output"
+	EventTaskEnd       EventType = "task-end"
+	EventWorkflowEnd   EventType = "workflow-end"
+)
+
+// Event is a single line of the stream.
+type Event struct {
+	Type     EventType `json:"type"`
+	Time     time.Time `json:"time"`
+	Workflow string    `json:"workflow,omitempty"`
+	Task     *Task     `json:"task,omitempty"`
+	// Text is set for output events only.
+	Text string `json:"text,omitempty"`
+	// Status, Duration and Error are set for end events only, status is either ok or failed.
+	Status string `json:"status,omitempty"`
+	// Duration in nanoseconds.
+	Duration time.Duration `json:"duration,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Task describes the task an event relates to.
+type Task struct {
+	// ID is unique within a single run.
+	ID string `json:"id"`
+	// Path consists of names of all groups the task belongs to, and its own name, e.g. build/go-build.
+	Path string `json:"path"`
+	Name string `json:"name"`
+	// Description is rendered during the execution, e.g. a command with all the arguments.
+	Description string `json:"description,omitempty"`
+	// NodeType is the type of the graph node behind the task, e.g. Middle or MiddleBeginning.
+	NodeType string `json:"node_type"`
+}
+
+var _ runner.Observer = &Runner{}
+
+type Runner struct {
+	engine *runner.Engine
+	enc    *json.Encoder
+	err    error
+}
+
+func New(w io.Writer) *Runner {
+	r := &Runner{
+		enc: json.NewEncoder(w),
+	}
+	r.engine = runner.New(r)
+	return r
+}
+
+// Observe registers additional observers.
+func (r *Runner) Observe(observers ...runner.Observer) {
+	r.engine.Observe(observers...)
+}
+
+func (r *Runner) Run(ctx context.Context, wf Workflow) error {
+	r.err = nil
+	if err := r.engine.Run(ctx, wf); err != nil {
+		return err
+	}
+	return r.err
+}
+
+// OnWorkflowStart implements runner.Observer interface.
+func (r *Runner) OnWorkflowStart(wf *runner.Workflow) {
+	r.emit(Event{Type: EventWorkflowStart, Workflow: wf.Name})
+}
+
+// OnTaskStart implements runner.Observer interface.
+func (r *Runner) OnTaskStart(t *runner.Task) {
+	r.emit(Event{Type: EventTaskStart, Task: task(t)})
+}
+
+// OnOutput implements runner.Observer interface.
+func (r *Runner) OnOutput(t *runner.Task, text string) {
+	r.emit(Event{Type: EventOutput, Task: task(t), Text: text})
+}
+
+// OnTaskEnd implements runner.Observer interface.
+func (r *Runner) OnTaskEnd(t *runner.Task) {
+	r.emit(Event{
+		Type:     EventTaskEnd,
+		Task:     task(t),
+		Status:   string(t.Status),
+		Duration: t.Duration,
+		Error:    errorString(t.Err),
+	})
+}
+
+// OnWorkflowEnd implements runner.Observer interface.
+func (r *Runner) OnWorkflowEnd(wf *runner.Workflow) {
+	r.emit(Event{
+		Type:     EventWorkflowEnd,
+		Workflow: wf.Name,
+		Status:   string(wf.Status),
+		Duration: wf.Duration,
+		Error:    errorString(wf.Err),
+	})
+}
+
+func task(t *runner.Task) *Task {
+	return &Task{
+		ID:          t.ID,
+		Path:        t.Path,
+		Name:        t.Name,
+		Description: t.Description,
+		NodeType:    strings.TrimPrefix(t.Type.String(), "Type"),
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func (r *Runner) emit(e Event) {
+	if r.err != nil {
+		return
+	}
+	e.Time = time.Now()
+	r.err = r.enc.Encode(e)
+}
+
+func Run(ctx context.Context, w io.Writer, wf Workflow) error {
+	return New(w).Run(ctx, wf)
+}
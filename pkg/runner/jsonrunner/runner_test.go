@@ -0,0 +1,75 @@
+package jsonrunner_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/runner/jsonrunner"
+)
+
+func TestRun(t *testing.T) {
+	g := rosie.Group("ci")
+	g.Beginning().
+		Then(rosie.Cmd("build", "echo", "building")).
+		Then(rosie.Fn("vet", func(_ context.Context, w io.Writer, _ rosie.Resulter) (interface{}, error) {
+			_, _ = fmt.Fprintln(w, "vetting")
+			return nil, errors.New("vet failure")
+		}))
+
+	buf := bytes.NewBuffer(nil)
+	if err := jsonrunner.Run(context.Background(), buf, g); err == nil || err.Error() != "vet failure" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []jsonrunner.Event
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var e jsonrunner.Event
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Time.IsZero() {
+			t.Errorf("event without time: %+v", e)
+		}
+		got = append(got, e)
+	}
+
+	type event struct {
+		typ                         jsonrunner.EventType
+		workflow, path, description string
+		text, status, error         string
+	}
+	exp := []event{
+		{typ: jsonrunner.EventWorkflowStart, workflow: "ci"},
+		{typ: jsonrunner.EventTaskStart, path: "ci"},
+		{typ: jsonrunner.EventTaskEnd, path: "ci", status: "ok"},
+		{typ: jsonrunner.EventTaskStart, path: "ci/build", description: "echo building"},
+		{typ: jsonrunner.EventOutput, path: "ci/build", description: "echo building", text: "building"},
+		{typ: jsonrunner.EventTaskEnd, path: "ci/build", description: "echo building", status: "ok"},
+		{typ: jsonrunner.EventTaskStart, path: "ci/vet"},
+		{typ: jsonrunner.EventOutput, path: "ci/vet", text: "vetting"},
+		{typ: jsonrunner.EventTaskEnd, path: "ci/vet", status: "failed", error: "vet failure"},
+		{typ: jsonrunner.EventWorkflowEnd, workflow: "ci", status: "failed", error: "vet failure"},
+	}
+	if len(got) != len(exp) {
+		t.Fatalf("wrong number of events: %d, expected %d: %+v", len(got), len(exp), got)
+	}
+	for i, e := range got {
+		ev := event{typ: e.Type, workflow: e.Workflow, text: e.Text, status: e.Status, error: e.Error}
+		if e.Task != nil {
+			ev.path, ev.description = e.Task.Path, e.Task.Description
+			if e.Task.ID == "" {
+				t.Errorf("event %d without task ID: %+v", i+1, e.Task)
+			}
+		}
+		if ev != exp[i] {
+			t.Errorf("wrong event %d, expected:\n%+v\nbut got:\n%+v", i+1, exp[i], ev)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// Package junit provides an observer that reports a run in the JUnit XML format, understood by most CI systems.
+//
+// Each group becomes a test suite and each task becomes a test case:
+//
+//	rep := junit.NewReporter()
+//	r := clirunner.New(&draw.Drawer{W: os.Stdout}, clirunner.VerbosityOpts{})
+//	r.Observe(rep)
+//	_, err := r.Run(ctx, group)
+//	if err := rep.WriteFile("report.xml"); err != nil {
+//		...
+//	}
+package junit
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/travelaudience/rosie/pkg/dag"
+	"github.com/travelaudience/rosie/pkg/runner"
+)
+
+var _ runner.Observer = &Reporter{}
+
+// TestSuites is the root element of a report.
+type TestSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr,omitempty"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     float64      `xml:"time,attr"`
+	Suites   []*TestSuite `xml:"testsuite"`
+}
+
+// TestSuite corresponds to a group of tasks.
+type TestSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      float64     `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr,omitempty"`
+	Cases     []*TestCase `xml:"testcase"`
+}
+
+// TestCase corresponds to a single task.
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Time      float64  `xml:"time,attr"`
+	Failure   *Failure `xml:"failure,omitempty"`
+	Skipped   *Skipped `xml:"skipped,omitempty"`
+	SystemOut string   `xml:"system-out,omitempty"`
+}
+
+// Failure carries the error a task failed with.
+type Failure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// Skipped marks a task that was not reached.
+type Skipped struct{}
+
+// Reporter collects results of a run, it implements runner.Observer interface.
+type Reporter struct {
+	report *TestSuites
+	suites map[*dag.Node]*TestSuite
+	output map[string]*bytes.Buffer
+}
+
+func NewReporter() *Reporter {
+	return &Reporter{}
+}
+
+// Report returns the report of the last run.
+func (r *Reporter) Report() *TestSuites {
+	return r.report
+}
+
+// OnWorkflowStart implements runner.Observer interface.
+func (r *Reporter) OnWorkflowStart(wf *runner.Workflow) {
+	r.report = &TestSuites{Name: wf.Name}
+	r.suites = make(map[*dag.Node]*TestSuite)
+	r.output = make(map[string]*bytes.Buffer)
+}
+
+// OnTaskStart implements runner.Observer interface.
+func (r *Reporter) OnTaskStart(t *runner.Task) {
+	if isGroup(t.Type) {
+		suite := &TestSuite{
+			Name:      t.Path,
+			Timestamp: t.Start.UTC().Format(time.RFC3339),
+		}
+		r.suites[t.Joint.Node()] = suite
+		r.report.Suites = append(r.report.Suites, suite)
+	}
+	r.output[t.ID] = bytes.NewBuffer(nil)
+}
+
+// OnOutput implements runner.Observer interface.
+func (r *Reporter) OnOutput(t *runner.Task, text string) {
+	buf := r.output[t.ID]
+	buf.WriteString(text)
+	buf.WriteRune('\n')
+}
+
+// OnTaskEnd implements runner.Observer interface.
+func (r *Reporter) OnTaskEnd(t *runner.Task) {
+	if !t.Reportable() {
+		return
+	}
+
+	suite := r.suite(t)
+	tc := &TestCase{
+		Name:      t.Name,
+		ClassName: suite.Name,
+		Time:      t.Duration.Seconds(),
+		SystemOut: r.output[t.ID].String(),
+	}
+	if t.Description != "" {
+		tc.Name += ": " + t.Description
+	}
+	if t.Err != nil {
+		tc.Failure = &Failure{
+			Message: firstLine(t.Err.Error()),
+			Text:    t.Err.Error(),
+		}
+		suite.Failures++
+		r.report.Failures++
+	}
+	suite.Cases = append(suite.Cases, tc)
+	suite.Tests++
+	suite.Time += tc.Time
+	r.report.Tests++
+}
+
+// OnWorkflowEnd implements runner.Observer interface.
+func (r *Reporter) OnWorkflowEnd(wf *runner.Workflow) {
+	r.report.Time = wf.Duration.Seconds()
+	for _, t := range wf.Skipped {
+		suite := r.skippedSuite(t)
+		suite.Cases = append(suite.Cases, &TestCase{
+			Name:      t.Name,
+			ClassName: suite.Name,
+			Skipped:   &Skipped{},
+		})
+		suite.Tests++
+		suite.Skipped++
+		r.report.Tests++
+		r.report.Skipped++
+	}
+
+	suites := r.report.Suites[:0]
+	for _, s := range r.report.Suites {
+		if s.Tests > 0 {
+			suites = append(suites, s)
+		}
+	}
+	r.report.Suites = suites
+}
+
+// WriteTo writes the report of the last run as XML.
+func (r *Reporter) WriteTo(w io.Writer) (int64, error) {
+	buf := bytes.NewBufferString(xml.Header)
+	enc := xml.NewEncoder(buf)
+	enc.Indent("", "\t")
+	if err := enc.Encode(r.report); err != nil {
+		return 0, err
+	}
+	buf.WriteRune('\n')
+
+	return buf.WriteTo(w)
+}
+
+// WriteFile writes the report of the last run into a file.
+func (r *Reporter) WriteFile(path string) error {
+	buf := bytes.NewBuffer(nil)
+	if _, err := r.WriteTo(buf); err != nil {
+		return err
+	}
+
+	/* #nosec */
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// suite returns the suite of the innermost group the task belongs to.
+func (r *Reporter) suite(t *runner.Task) *TestSuite {
+	scope := t.Joint.Node().Scope()
+	for i := len(scope) - 1; i >= 0; i-- {
+		if suite, ok := r.suites[scope[i]]; ok {
+			return suite
+		}
+	}
+
+	// The task does not belong to any group that was started during the run, e.g. the workflow is not a group.
+	suite := &TestSuite{Name: t.Path}
+	r.suites[t.Joint.Node()] = suite
+	r.report.Suites = append(r.report.Suites, suite)
+	return suite
+}
+
+// skippedSuite returns the suite of the group the skipped task belongs to, groups are skipped along with their tasks.
+func (r *Reporter) skippedSuite(t *runner.Task) *TestSuite {
+	scope := t.Joint.Node().Scope()
+	if len(scope) == 0 {
+		return r.suite(t)
+	}
+	group := scope[len(scope)-1]
+	if suite, ok := r.suites[group]; ok {
+		return suite
+	}
+	suite := &TestSuite{Name: group.Path()}
+	r.suites[group] = suite
+	r.report.Suites = append(r.report.Suites, suite)
+	return suite
+}
+
+func isGroup(t dag.Type) bool {
+	return t == dag.TypeBeginning || t == dag.TypeMiddleBeginning
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexRune(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
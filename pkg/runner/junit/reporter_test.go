@@ -0,0 +1,78 @@
+package junit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/runner"
+	"github.com/travelaudience/rosie/pkg/runner/junit"
+)
+
+func TestReporter(t *testing.T) {
+	lint := rosie.Group("lint")
+	lint.Beginning().
+		Then(rosie.Fn("vet", func(_ context.Context, w io.Writer, _ rosie.Resulter) (interface{}, error) {
+			_, _ = fmt.Fprintln(w, "vetting")
+			return nil, errors.New("vet failure")
+		}))
+
+	deploy := rosie.Group("deploy")
+	deploy.Beginning().Then(rosie.Cmd("push", "echo", "pushing"))
+
+	g := rosie.Group("ci")
+	g.Beginning().
+		Then(rosie.Cmd("build", "echo", "building")).
+		Then(lint).
+		Then(deploy)
+
+	rep := junit.NewReporter()
+	if err := runner.New(rep).Run(context.Background(), g); err == nil {
+		t.Fatal("expected error")
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if _, err := rep.WriteTo(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var got junit.TestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "ci" || got.Tests != 3 || got.Failures != 1 || got.Skipped != 1 {
+		t.Fatalf("wrong summary: %s, tests: %d, failures: %d, skipped: %d", got.Name, got.Tests, got.Failures, got.Skipped)
+	}
+	if len(got.Suites) != 3 {
+		t.Fatalf("wrong number of suites: %d", len(got.Suites))
+	}
+
+	build := got.Suites[0]
+	if build.Name != "ci" || len(build.Cases) != 1 {
+		t.Fatalf("wrong suite: %+v", build)
+	}
+	if tc := build.Cases[0]; tc.Name != "build: echo building" || tc.SystemOut != "building\n" || tc.Failure != nil {
+		t.Errorf("wrong test case: %+v", tc)
+	}
+
+	vet := got.Suites[1]
+	if vet.Name != "ci/lint" || vet.Failures != 1 || len(vet.Cases) != 1 {
+		t.Fatalf("wrong suite: %+v", vet)
+	}
+	if tc := vet.Cases[0]; tc.ClassName != "ci/lint" || tc.Failure == nil || tc.Failure.Message != "vet failure" || tc.SystemOut != "vetting\n" {
+		t.Errorf("wrong test case: %+v", tc)
+	}
+
+	push := got.Suites[2]
+	if push.Name != "ci/deploy" || push.Skipped != 1 || len(push.Cases) != 1 {
+		t.Fatalf("wrong suite: %+v", push)
+	}
+	if tc := push.Cases[0]; tc.Name != "push" || tc.Skipped == nil || tc.Failure != nil {
+		t.Errorf("wrong test case: %+v", tc)
+	}
+}
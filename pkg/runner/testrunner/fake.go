@@ -0,0 +1,220 @@
+package testrunner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/travelaudience/rosie"
+)
+
+var _ rosie.Commander = &FakeCommander{}
+
+// FakeCommander replaces programs started by CmdTask with scripted responses, so workflows can be tested hermetically:
+//
+//	fake := &testrunner.FakeCommander{}
+//	fake.On("go", "build", "...").Stdout("ok")
+//	fake.On("go", "test", "*").Stderr("FAIL").ExitCode(1)
+//	rec := testrunner.ExecuteContext(rosie.WithCommander(ctx, fake), t, group)
+//	fake.AssertCalled(t, "go", "build", "./...")
+//
+// Commands that match none of the rules fail.
+type FakeCommander struct {
+	rules []*FakeCommand
+	calls []Invocation
+	lock  sync.Mutex
+}
+
+// FakeCommand is a scripted response to commands matching a pattern.
+type FakeCommand struct {
+	pattern        []string
+	stdout, stderr string
+	code           int
+}
+
+// Invocation describes a command started through FakeCommander.
+type Invocation struct {
+	// Args include the name of the program, e.g. [go build ./...].
+	Args []string
+	Dir  string
+	Env  []string
+}
+
+// String implements fmt.Stringer interface.
+func (i Invocation) String() string {
+	return strings.Join(i.Args, " ")
+}
+
+// ExitError is returned by fake commands that exit with non-zero code.
+type ExitError struct {
+	Code int
+}
+
+// Error implements error interface.
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// On registers a response to commands matching the pattern, rules registered later take precedence.
+// Each element is matched against a single argument, * matches any sequence of characters and ? a single one.
+// The last element can be "..." to match any number of remaining arguments.
+func (f *FakeCommander) On(pattern ...string) *FakeCommand {
+	c := &FakeCommand{pattern: pattern}
+
+	f.lock.Lock()
+	f.rules = append(f.rules, c)
+	f.lock.Unlock()
+
+	return c
+}
+
+// Stdout sets the standard output of the command.
+func (c *FakeCommand) Stdout(text string) *FakeCommand {
+	c.stdout = text
+	return c
+}
+
+// Stderr sets the standard error of the command.
+func (c *FakeCommand) Stderr(text string) *FakeCommand {
+	c.stderr = text
+	return c
+}
+
+// ExitCode sets the exit code of the command, non-zero code makes the task fail.
+func (c *FakeCommand) ExitCode(code int) *FakeCommand {
+	c.code = code
+	return c
+}
+
+// Invocations returns all commands started so far, in order.
+func (f *FakeCommander) Invocations() []Invocation {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return append([]Invocation(nil), f.calls...)
+}
+
+// Called returns the invocations matching the pattern, see On for the syntax.
+func (f *FakeCommander) Called(pattern ...string) []Invocation {
+	var res []Invocation
+	for _, inv := range f.Invocations() {
+		if match(pattern, inv.Args) {
+			res = append(res, inv)
+		}
+	}
+	return res
+}
+
+// AssertCalled checks that a command matching the pattern was started.
+func (f *FakeCommander) AssertCalled(t testing.TB, pattern ...string) {
+	t.Helper()
+	if len(f.Called(pattern...)) == 0 {
+		t.Errorf("command %s was not called, calls: %v", strings.Join(pattern, " "), f.Invocations())
+	}
+}
+
+// AssertNotCalled checks that no command matching the pattern was started.
+func (f *FakeCommander) AssertNotCalled(t testing.TB, pattern ...string) {
+	t.Helper()
+	if calls := f.Called(pattern...); len(calls) > 0 {
+		t.Errorf("command %s should not be called, calls: %v", strings.Join(pattern, " "), calls)
+	}
+}
+
+// Start implements rosie.Commander interface.
+func (f *FakeCommander) Start(ctx context.Context, cmd *exec.Cmd) (rosie.Process, error) {
+	inv := Invocation{
+		Args: append([]string(nil), cmd.Args...),
+		Dir:  cmd.Dir,
+		Env:  append([]string(nil), cmd.Env...),
+	}
+
+	f.lock.Lock()
+	f.calls = append(f.calls, inv)
+	var rule *FakeCommand
+	for i := len(f.rules) - 1; i >= 0; i-- {
+		if match(f.rules[i].pattern, inv.Args) {
+			rule = f.rules[i]
+			break
+		}
+	}
+	f.lock.Unlock()
+
+	if rule == nil {
+		return nil, fmt.Errorf("no fake command matches: %s", inv)
+	}
+	return &fakeProcess{ctx: ctx, cmd: cmd, rule: rule}, nil
+}
+
+type fakeProcess struct {
+	ctx  context.Context
+	cmd  *exec.Cmd
+	rule *FakeCommand
+}
+
+// Wait implements rosie.Process interface.
+func (p *fakeProcess) Wait() (int, error) {
+	if err := p.ctx.Err(); err != nil {
+		return -1, err
+	}
+	if err := write(p.cmd.Stdout, p.rule.stdout); err != nil {
+		return -1, err
+	}
+	if err := write(p.cmd.Stderr, p.rule.stderr); err != nil {
+		return -1, err
+	}
+	if p.rule.code != 0 {
+		return p.rule.code, &ExitError{Code: p.rule.code}
+	}
+	return 0, nil
+}
+
+func write(w io.Writer, text string) error {
+	if w == nil || text == "" {
+		return nil
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	_, err := io.WriteString(w, text)
+	return err
+}
+
+func match(pattern, args []string) bool {
+	for i, p := range pattern {
+		if p == "..." && i == len(pattern)-1 {
+			return true
+		}
+		if i >= len(args) {
+			return false
+		}
+		if !glob(p, args[i]) {
+			return false
+		}
+	}
+	return len(pattern) == len(args)
+}
+
+// glob matches the text against the pattern, * matches any sequence of characters (including slashes) and ? a single one.
+func glob(pattern, text string) bool {
+	var expr strings.Builder
+	expr.WriteString("(?s)^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteRune('.')
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteRune('$')
+
+	return regexp.MustCompile(expr.String()).MatchString(text)
+}
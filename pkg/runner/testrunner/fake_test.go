@@ -0,0 +1,60 @@
+package testrunner_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/runner/testrunner"
+)
+
+func TestFakeCommander(t *testing.T) {
+	fake := &testrunner.FakeCommander{}
+	fake.On("go", "...").Stdout("go version go1.12")
+	fake.On("go", "build", "*").Stdout("built\n")
+	fake.On("go", "test", "./pkg/*").Stdout("ok").Stderr("warning").ExitCode(2)
+
+	g := rosie.Group("build")
+	g.Beginning().
+		Then(rosie.Cmd("version", "go", "version")).
+		Then(rosie.Dir(rosie.Cmd("build", "go", "build", "./..."), "/src")).
+		Then(rosie.Cmd("test", "go", "test", "./pkg/dag")).
+		Then(rosie.Cmd("never", "go", "vet"))
+
+	rec := testrunner.ExecuteContext(rosie.WithCommander(context.Background(), fake), t, g)
+	if rec.Err == nil || rec.Err.Error() != "exit status 2" {
+		t.Fatalf("unexpected error: %v", rec.Err)
+	}
+
+	testrunner.AssertResult(t, rec, "version", []string{"go version go1.12"})
+	testrunner.AssertResult(t, rec, "dir(build)", []string{"built"})
+	testrunner.AssertOutputContains(t, rec, "test", "ok")
+	testrunner.AssertOutputContains(t, rec, "test", "warning")
+	testrunner.AssertFailed(t, rec, "test")
+	testrunner.AssertSkipped(t, rec, "never")
+
+	fake.AssertCalled(t, "go", "build", "./...")
+	fake.AssertCalled(t, "go", "test", "...")
+	fake.AssertNotCalled(t, "go", "vet")
+	calls := fake.Invocations()
+	if len(calls) != 3 || calls[1].Dir != "/src" || calls[2].String() != "go test ./pkg/dag" {
+		t.Errorf("unexpected invocations: %v", calls)
+	}
+
+	s := &spy{TB: t}
+	fake.AssertCalled(s, "go", "vet")
+	fake.AssertNotCalled(s, "go", "...")
+	if len(s.failures) != 2 {
+		t.Errorf("expected every assertion to fail, got: %q", s.failures)
+	}
+}
+
+func TestFakeCommander_unmatched(t *testing.T) {
+	g := rosie.Group("build")
+	g.Beginning().Then(rosie.Cmd("ls", "ls", "-lha"))
+
+	rec := testrunner.ExecuteContext(rosie.WithCommander(context.Background(), &testrunner.FakeCommander{}), t, g)
+	if rec.Err == nil || rec.Err.Error() != "no fake command matches: ls -lha" {
+		t.Fatalf("unexpected error: %v", rec.Err)
+	}
+}
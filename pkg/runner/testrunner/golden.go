@@ -0,0 +1,152 @@
+package testrunner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/dag"
+)
+
+var _ Grapher = &rosie.GroupTask{}
+
+// UpdateEnv is the environment variable that makes AssertGolden write golden files instead of comparing them, e.g. ROSIE_UPDATE_GOLDEN=1 go test ./...
+const UpdateEnv = "ROSIE_UPDATE_GOLDEN"
+
+// Grapher is implemented by anything that is anchored in a graph, e.g. rosie.GroupTask.
+type Grapher interface {
+	Node() *dag.Node
+}
+
+// SnapshotOpts controls what a snapshot consists of.
+type SnapshotOpts struct {
+	// Result is passed to templates of commands as the result of the previous task, e.g. [[.Result.Value]].
+	Result interface{}
+	// Params are passed to templates of commands as parameters of the workflow, e.g. [[.Params.version]].
+	Params map[string]interface{}
+	// Record adds the outcome of a run (without durations), e.g. one returned by Execute.
+	Record *Record
+}
+
+// Snapshot serializes the structure of the workflow: nodes with their types and rendered commands, edges and the tree of names.
+// Nodes are identified by their position in the topological order, so the snapshot is stable across runs.
+func Snapshot(g Grapher, opts SnapshotOpts) (string, error) {
+	sorted, err := dag.TopologicalSort(g.Node())
+	if err != nil {
+		return "", err
+	}
+
+	ids := make(map[*dag.Node]string, len(sorted))
+	for i, n := range sorted {
+		ids[n] = fmt.Sprintf("n%d", i+1)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString("# nodes\n")
+	for _, n := range sorted {
+		_, _ = fmt.Fprintf(buf, "%s %s %s", ids[n], strings.TrimPrefix(n.Type().String(), "Type"), n.Path())
+		if r, ok := n.Data.(interface {
+			RenderContext(context.Context, interface{}) (string, error)
+		}); ok {
+			desc, err := r.RenderContext(rosie.WithParams(context.Background(), opts.Params), opts.Result)
+			if err != nil {
+				return "", err
+			}
+			_, _ = fmt.Fprintf(buf, ": %s", desc)
+		}
+		buf.WriteRune('\n')
+	}
+
+	buf.WriteString("\n# edges\n")
+	for _, n := range sorted {
+		for _, child := range n.Children() {
+			if id, ok := ids[child]; ok {
+				_, _ = fmt.Fprintf(buf, "%s -> %s\n", ids[n], id)
+			}
+		}
+	}
+
+	buf.WriteString("\n# tree\n")
+	buf.WriteString(g.Node().GoString())
+
+	if rec := opts.Record; rec != nil {
+		buf.WriteString("\n# record\n")
+		for _, t := range rec.Tasks {
+			_, _ = fmt.Fprintf(buf, "%d %s %s", t.Order, t.Status, t.Path)
+			if t.Description != "" {
+				_, _ = fmt.Fprintf(buf, ": %s", t.Description)
+			}
+			buf.WriteRune('\n')
+			for _, line := range t.Output {
+				_, _ = fmt.Fprintf(buf, "  | %s\n", line)
+			}
+			if t.Err != nil {
+				_, _ = fmt.Fprintf(buf, "  error: %s\n", t.Err)
+			}
+		}
+		if rec.Err != nil {
+			_, _ = fmt.Fprintf(buf, "error: %s\n", rec.Err)
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// AssertGolden compares the snapshot of the workflow with the golden file, e.g. testdata/build.golden.
+// Run tests with UpdateEnv set to write the current snapshot into the file instead.
+func AssertGolden(t testing.TB, path string, g Grapher, opts SnapshotOpts) {
+	t.Helper()
+
+	got, err := Snapshot(g, opts)
+	if err != nil {
+		t.Fatalf("snapshot failure: %s", err)
+	}
+
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	/* #nosec */
+	exp, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("golden file cannot be read (run with %s=1 to create it): %s", UpdateEnv, err)
+	}
+	if got != string(exp) {
+		t.Errorf("snapshot does not match %s (run with %s=1 to accept it):\n%s", path, UpdateEnv, diff(string(exp), got))
+	}
+}
+
+// diff lists lines that differ, prefixed with - (expected) and + (got).
+func diff(exp, got string) string {
+	var (
+		el  = strings.Split(exp, "\n")
+		gl  = strings.Split(got, "\n")
+		buf = bytes.NewBuffer(nil)
+	)
+	for i := 0; i < len(el) || i < len(gl); i++ {
+		var e, g string
+		if i < len(el) {
+			e = el[i]
+		}
+		if i < len(gl) {
+			g = gl[i]
+		}
+		if e == g {
+			continue
+		}
+		_, _ = fmt.Fprintf(buf, "line %d:\n- %s\n+ %s\n", i+1, e, g)
+	}
+	return buf.String()
+}
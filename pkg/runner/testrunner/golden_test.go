@@ -0,0 +1,82 @@
+package testrunner_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/runner/testrunner"
+)
+
+func golden() *rosie.GroupTask {
+	test := rosie.Group("test")
+	test.Beginning().
+		Then(rosie.Cmd("unit", "go", "test", "[[.Result.Value]]")).
+		Then(rosie.Dir(rosie.Cmd("e2e", "go", "test", "./e2e"), "tests"))
+
+	g := rosie.Group("build")
+	g.Beginning().
+		Then(rosie.Cmd("build", "go", "build", "[[.Params.packages]]")).
+		Then(test)
+	return g
+}
+
+func TestAssertGolden(t *testing.T) {
+	testrunner.AssertGolden(t, "testdata/plan.golden", golden(), testrunner.SnapshotOpts{
+		Result: "./...",
+		Params: map[string]interface{}{"packages": "./..."},
+	})
+}
+
+func TestAssertGolden_record(t *testing.T) {
+	fake := &testrunner.FakeCommander{}
+	fake.On("go", "build", "...").Stdout("built")
+	fake.On("go", "test", "...").Stdout("ok")
+	fake.On("go", "test", "./e2e").Stderr("FAIL").ExitCode(1)
+
+	params := map[string]interface{}{"packages": "./..."}
+	ctx := rosie.WithParams(rosie.WithCommander(context.Background(), fake), params)
+
+	g := golden()
+	rec := testrunner.ExecuteContext(ctx, t, g)
+
+	testrunner.AssertGolden(t, "testdata/record.golden", g, testrunner.SnapshotOpts{
+		Result: "./...",
+		Params: params,
+		Record: rec,
+	})
+}
+
+func TestAssertGolden_mismatch(t *testing.T) {
+	if os.Getenv(testrunner.UpdateEnv) != "" {
+		t.Skip("golden files are being updated")
+	}
+
+	s := &spy{TB: t}
+	testrunner.AssertGolden(s, "testdata/plan.golden", golden(), testrunner.SnapshotOpts{
+		Result: "./...",
+		Params: map[string]interface{}{"packages": "./pkg/..."},
+	})
+	if len(s.failures) != 1 || !strings.Contains(s.failures[0], "+ n2 Middle build/build: go build ./pkg/...") {
+		t.Errorf("unexpected failures: %q", s.failures)
+	}
+}
+
+func TestSnapshot_params(t *testing.T) {
+	g := rosie.Group("release")
+	g.Beginning().
+		Then(rosie.Cmd("tag", "git", "tag", "[[.Params.version]]", "[[.Result.Value]]"))
+
+	got, err := testrunner.Snapshot(g, testrunner.SnapshotOpts{
+		Result: "HEAD",
+		Params: map[string]interface{}{"version": "v1.0.0"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "n2 Middle release/tag: git tag v1.0.0 HEAD\n") {
+		t.Errorf("unexpected snapshot:\n%s", got)
+	}
+}
@@ -0,0 +1,242 @@
+package testrunner
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/dag"
+	"github.com/travelaudience/rosie/pkg/runner"
+)
+
+// Record describes a run, it is meant to be queried by assertions:
+//
+//	rec := testrunner.Execute(t, group)
+//	testrunner.AssertRan(t, rec, "go-build")
+//	testrunner.AssertOrder(t, rec, "go-build", "go-test")
+type Record struct {
+	// Err is the error the run failed with.
+	Err error
+	// Tasks are listed in the order of execution, followed by the ones that were skipped.
+	Tasks []*TaskRecord
+}
+
+// TaskRecord describes a single task of a run.
+type TaskRecord struct {
+	Name string
+	// Path consists of names of all groups the task belongs to, and its own name, e.g. build/go-build.
+	Path        string
+	Description string
+	Status      runner.Status
+	Err         error
+	// Result is the value the task produced, tasks that do not produce anything pass on the result of the previous one.
+	Result interface{}
+	Output []string
+	// Order is the position of the task in the order of execution, starting at 1, it is 0 for skipped tasks.
+	Order int
+}
+
+// Task returns the first task with the given name or path, or nil.
+func (r *Record) Task(name string) *TaskRecord {
+	if tasks := r.Find(name); len(tasks) > 0 {
+		return tasks[0]
+	}
+	return nil
+}
+
+// Find returns all tasks with the given name or path, e.g. all iterations of a ForEach group.
+func (r *Record) Find(name string) []*TaskRecord {
+	var res []*TaskRecord
+	for _, t := range r.Tasks {
+		if t.Name == name || t.Path == name {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// Ran reports whether a task with the given name or path was executed.
+func (r *Record) Ran(name string) bool {
+	for _, t := range r.Find(name) {
+		if t.Status != runner.StatusSkipped {
+			return true
+		}
+	}
+	return false
+}
+
+// Executed returns paths of executed tasks, in the order of execution.
+func (r *Record) Executed() []string {
+	var res []string
+	for _, t := range r.Tasks {
+		if t.Status != runner.StatusSkipped {
+			res = append(res, t.Path)
+		}
+	}
+	return res
+}
+
+// Execute runs the workflow and records it, the output of tasks is passed to the test log.
+// Failures are not reported, they are available as Record.Err.
+func Execute(t testing.TB, prov runner.Iterator) *Record {
+	return ExecuteContext(context.Background(), t, prov)
+}
+
+// ExecuteContext is like Execute, the context is passed down to tasks, e.g. to inject a FakeCommander.
+func ExecuteContext(ctx context.Context, t testing.TB, prov runner.Iterator) *Record {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	rec := &recorder{
+		t:     t,
+		tasks: make(map[string]*TaskRecord),
+	}
+	err := runner.New(rec).Run(ctx, prov)
+
+	return &Record{
+		Err:   err,
+		Tasks: rec.order,
+	}
+}
+
+var _ runner.Observer = &recorder{}
+
+// recorder builds the record of a run.
+type recorder struct {
+	t     testing.TB
+	order []*TaskRecord
+	tasks map[string]*TaskRecord
+}
+
+// OnWorkflowStart implements runner.Observer interface.
+func (r *recorder) OnWorkflowStart(*runner.Workflow) {}
+
+// OnTaskStart implements runner.Observer interface.
+func (r *recorder) OnTaskStart(t *runner.Task) {
+	if !t.Executable || t.Type != dag.TypeMiddle {
+		return
+	}
+
+	tr := &TaskRecord{
+		Name:        t.Name,
+		Path:        t.Path,
+		Description: t.Description,
+		Status:      t.Status,
+		Order:       len(r.order) + 1,
+	}
+	r.tasks[t.ID] = tr
+	r.order = append(r.order, tr)
+}
+
+// OnOutput implements runner.Observer interface.
+func (r *recorder) OnOutput(t *runner.Task, text string) {
+	r.t.Log(text)
+	if tr, ok := r.tasks[t.ID]; ok {
+		tr.Output = append(tr.Output, text)
+	}
+}
+
+// OnTaskEnd implements runner.Observer interface.
+func (r *recorder) OnTaskEnd(t *runner.Task) {
+	tr, ok := r.tasks[t.ID]
+	if !ok {
+		return
+	}
+	tr.Status = t.Status
+	tr.Err = t.Err
+	if res, ok := t.Joint.(rosie.Resulter); ok && t.Err == nil {
+		tr.Result = res.Result().Value()
+	}
+}
+
+// OnWorkflowEnd implements runner.Observer interface.
+func (r *recorder) OnWorkflowEnd(wf *runner.Workflow) {
+	for _, t := range wf.Skipped {
+		r.order = append(r.order, &TaskRecord{
+			Name:   t.Name,
+			Path:   t.Path,
+			Status: t.Status,
+		})
+	}
+}
+
+// AssertRan checks that a task with the given name or path was executed.
+func AssertRan(t testing.TB, rec *Record, name string) {
+	t.Helper()
+	if !rec.Ran(name) {
+		t.Errorf("task %s did not run, executed: %s", name, strings.Join(rec.Executed(), ", "))
+	}
+}
+
+// AssertSkipped checks that a task with the given name or path was not reached.
+func AssertSkipped(t testing.TB, rec *Record, name string) {
+	t.Helper()
+	if tasks := rec.Find(name); len(tasks) == 0 {
+		t.Errorf("task %s is unknown", name)
+	} else if rec.Ran(name) {
+		t.Errorf("task %s should not run", name)
+	}
+}
+
+// AssertFailed checks that a task with the given name or path failed.
+func AssertFailed(t testing.TB, rec *Record, name string) {
+	t.Helper()
+	for _, tsk := range rec.Find(name) {
+		if tsk.Status == runner.StatusFailed {
+			return
+		}
+	}
+	t.Errorf("task %s should fail", name)
+}
+
+// AssertResult checks that the first task with the given name or path produced the expected value.
+func AssertResult(t testing.TB, rec *Record, name string, exp interface{}) {
+	t.Helper()
+	tsk := rec.Task(name)
+	if tsk == nil || tsk.Status == runner.StatusSkipped {
+		t.Errorf("task %s did not run", name)
+		return
+	}
+	if !reflect.DeepEqual(tsk.Result, exp) {
+		t.Errorf("task %s produced unexpected result, got %#v, expected %#v", name, tsk.Result, exp)
+	}
+}
+
+// AssertOutputContains checks that any of the tasks with the given name or path emitted a line containing the text.
+func AssertOutputContains(t testing.TB, rec *Record, name, text string) {
+	t.Helper()
+	tasks := rec.Find(name)
+	for _, tsk := range tasks {
+		for _, line := range tsk.Output {
+			if strings.Contains(line, text) {
+				return
+			}
+		}
+	}
+	if len(tasks) == 0 {
+		t.Errorf("task %s is unknown", name)
+		return
+	}
+	t.Errorf("output of task %s does not contain %q", name, text)
+}
+
+// AssertOrder checks that the tasks (given by names or paths) were executed in the given order, other tasks may run in between.
+func AssertOrder(t testing.TB, rec *Record, names ...string) {
+	t.Helper()
+	last := 0
+	for _, name := range names {
+		tsk := rec.Task(name)
+		switch {
+		case tsk == nil || tsk.Order == 0:
+			t.Errorf("task %s did not run", name)
+			return
+		case tsk.Order < last:
+			t.Errorf("task %s ran too early, executed: %s", name, strings.Join(rec.Executed(), ", "))
+			return
+		}
+		last = tsk.Order
+	}
+}
@@ -0,0 +1,74 @@
+package testrunner_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/runner"
+	"github.com/travelaudience/rosie/pkg/runner/testrunner"
+)
+
+// spy records failures instead of failing the test.
+type spy struct {
+	testing.TB
+	failures []string
+}
+
+func (s *spy) Helper() {}
+
+func (s *spy) Errorf(format string, args ...interface{}) {
+	s.failures = append(s.failures, fmt.Sprintf(format, args...))
+}
+
+func TestExecute(t *testing.T) {
+	inner := rosie.Group("test")
+	inner.Beginning().
+		Then(rosie.Fn("answer", func(context.Context, io.Writer, rosie.Resulter) (interface{}, error) {
+			return 42, nil
+		})).
+		Then(rosie.Fn("fail", func(_ context.Context, w io.Writer, _ rosie.Resulter) (interface{}, error) {
+			_, _ = io.WriteString(w, "failing\n")
+			return nil, errors.New("failure")
+		}))
+
+	g := rosie.Group("build")
+	g.Beginning().
+		Then(rosie.Cmd("echo", "echo", "hello")).
+		Then(inner).
+		Then(rosie.Cmd("never", "echo", "never"))
+
+	rec := testrunner.Execute(t, g)
+	if rec.Err == nil || rec.Err.Error() != "failure" {
+		t.Fatalf("unexpected error: %v", rec.Err)
+	}
+
+	testrunner.AssertRan(t, rec, "echo")
+	testrunner.AssertRan(t, rec, "build/test/answer")
+	testrunner.AssertResult(t, rec, "answer", 42)
+	testrunner.AssertOutputContains(t, rec, "echo", "hello")
+	testrunner.AssertOutputContains(t, rec, "fail", "failing")
+	testrunner.AssertFailed(t, rec, "fail")
+	testrunner.AssertSkipped(t, rec, "never")
+	testrunner.AssertOrder(t, rec, "echo", "answer", "fail")
+
+	if got := rec.Task("echo"); got.Description != "echo hello" || got.Status != runner.StatusOK || got.Order != 1 {
+		t.Errorf("unexpected record: %+v", got)
+	}
+
+	s := &spy{TB: t}
+	testrunner.AssertRan(s, rec, "never")
+	testrunner.AssertSkipped(s, rec, "echo")
+	testrunner.AssertSkipped(s, rec, "unknown")
+	testrunner.AssertFailed(s, rec, "echo")
+	testrunner.AssertResult(s, rec, "answer", "42")
+	testrunner.AssertOutputContains(s, rec, "echo", "bye")
+	testrunner.AssertOrder(s, rec, "fail", "echo")
+	testrunner.AssertOrder(s, rec, "echo", "never")
+	if len(s.failures) != 8 {
+		t.Errorf("expected every assertion to fail, got: %q", s.failures)
+	}
+}
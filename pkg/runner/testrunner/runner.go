@@ -2,48 +2,62 @@ package testrunner
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
-	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/runner"
 )
 
-type iteratorProvider interface {
-	Iter() (*rosie.Iterator, error)
-}
-
-func Run(t *testing.T, prov iteratorProvider, assert func(*testing.T, error)) {
+// Run executes the workflow and passes every error to assert, it keeps going after a failure.
+// Output of tasks is passed to the test log.
+func Run(t *testing.T, prov runner.Iterator, assert func(*testing.T, error)) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
-	iter, err := prov.Iter()
-	if err != nil {
-		t.Fatal(err)
+	l := &logger{t: t, assert: assert}
+	e := runner.New(l)
+	e.ContinueOnFailure(true)
+	// The engine returns the first failure of a task, which is already asserted, or the failure of the iteration.
+	if err := e.Run(ctx, prov); err != nil && !l.asserted(err) {
+		assert(t, err)
 	}
+}
 
-	for {
-		tsk, ok := iter.Next()
-		if !ok {
-			break
-		}
+var _ runner.Observer = &logger{}
 
-		if rnr, ok := tsk.(rosie.Executor); ok {
-			out, err := rnr.Exec(ctx)
-			if err != nil {
-				assert(t, err)
-			}
+// logger passes the output of tasks to the test log and errors of failed tasks to the assertion.
+type logger struct {
+	t      *testing.T
+	assert func(*testing.T, error)
+	first  error
+}
 
-			drain(t, out, assert)
-		}
-	}
+func (l *logger) asserted(err error) bool {
+	return l.first != nil && reflect.TypeOf(err).Comparable() && err == l.first
 }
 
-func drain(t *testing.T, in <-chan rosie.Piece, assert func(*testing.T, error)) {
-	for piece := range in {
-		if piece.Err != nil {
-			assert(t, piece.Err)
-		}
+// OnWorkflowStart implements runner.Observer interface.
+func (l *logger) OnWorkflowStart(*runner.Workflow) {}
 
-		t.Log(piece.Text)
+// OnTaskStart implements runner.Observer interface.
+func (l *logger) OnTaskStart(*runner.Task) {}
+
+// OnOutput implements runner.Observer interface.
+func (l *logger) OnOutput(_ *runner.Task, text string) {
+	l.t.Log(text)
+}
+
+// OnTaskEnd implements runner.Observer interface.
+func (l *logger) OnTaskEnd(t *runner.Task) {
+	if t.Err == nil {
+		return
 	}
+	if l.first == nil {
+		l.first = t.Err
+	}
+	l.assert(l.t, t.Err)
 }
+
+// OnWorkflowEnd implements runner.Observer interface.
+func (l *logger) OnWorkflowEnd(*runner.Workflow) {}
@@ -0,0 +1,364 @@
+// Package docker provides tasks that build, tag, push and run images using the docker binary, e.g.:
+//
+//	g.Beginning().
+//		Then(git.Commit()).
+//		Then(docker.Build(docker.BuildOpts{
+//			Tags:      []string{"registry.example.com/app:[[.Result.Value]]"},
+//			BuildArgs: map[string]string{"COMMIT": "[[.Result.Value]]"},
+//			Platform:  "linux/amd64",
+//		})).
+//		Then(docker.Push(""))
+//
+// Options are templates, like commands of rosie.Cmd, executed with the result of the previous task.
+// Tasks produce Image, so the next one can refer to the image built or tagged before.
+// Programs are started through rosie.CommanderFrom, so the tasks can be tested with testrunner.FakeCommander.
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/internal/syncio"
+)
+
+// Image is the result of tasks of this package.
+type Image struct {
+	// ID is the content-addressable identifier of the image, e.g. sha256:4b82...
+	ID string
+	// Tags are the references the task built, tagged or pushed.
+	Tags []string
+	// Digest is the digest of the manifest of the first tag in the registry, it is known once the image is pushed.
+	Digest string
+	// Digests are digests reported for each of the pushed tags.
+	Digests map[string]string
+}
+
+// Ref returns the most specific reference of the image: the first tag pinned by the digest, the first tag or the ID.
+func (i Image) Ref() string {
+	switch {
+	case len(i.Tags) > 0 && i.Digest != "":
+		return repository(i.Tags[0]) + "@" + i.Digest
+	case len(i.Tags) > 0:
+		return i.Tags[0]
+	default:
+		return i.ID
+	}
+}
+
+// BuildOpts describes docker build.
+type BuildOpts struct {
+	// Context is the build context, it defaults to the current directory.
+	Context    string
+	Dockerfile string
+	Tags       []string
+	BuildArgs  map[string]string
+	// ResultArgs adds build args from the result of the previous task, it has to be a map[string]string.
+	ResultArgs bool
+	Labels     map[string]string
+	// Platform is the target platform, e.g. linux/arm64.
+	Platform string
+	// Target is the stage of a multi-stage build.
+	Target string
+}
+
+// RunOpts describes docker run, the container is removed once it exits.
+type RunOpts struct {
+	// Image defaults to the image produced by the previous task.
+	Image   string
+	Command []string
+	Env     map[string]string
+	// Volumes are bind mounts, e.g. /src:/go/src.
+	Volumes  []string
+	Workdir  string
+	Platform string
+}
+
+// Build builds an image and produces Image with its ID and tags.
+func Build(opts BuildOpts) *rosie.FnTask {
+	dir := opts.Context
+	if dir == "" {
+		dir = "."
+	}
+	desc := append([]string{"docker", "build"}, opts.Tags...)
+
+	return rosie.DescribedFn("docker-build", strings.Join(append(desc, dir), " "), func(ctx context.Context, w io.Writer, res rosie.Resulter) (interface{}, error) {
+		iid, err := ioutil.TempFile("", "rosie-iid-")
+		if err != nil {
+			return nil, err
+		}
+		_ = iid.Close()
+		defer func() { _ = os.Remove(iid.Name()) }()
+
+		tags, err := templates(ctx, res, opts.Tags...)
+		if err != nil {
+			return nil, err
+		}
+
+		args := []string{"build", "--iidfile", iid.Name()}
+		for _, tag := range tags {
+			args = append(args, "--tag", tag)
+		}
+		for _, f := range []struct{ flag, value string }{
+			{"--file", opts.Dockerfile},
+			{"--platform", opts.Platform},
+			{"--target", opts.Target},
+		} {
+			if f.value == "" {
+				continue
+			}
+			value, err := templates(ctx, res, f.value)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, f.flag, value[0])
+		}
+
+		buildArgs := make(map[string]string, len(opts.BuildArgs))
+		if opts.ResultArgs {
+			m, ok := res.Result().Value().(map[string]string)
+			if !ok {
+				return nil, fmt.Errorf("build args require map[string]string, got %T", res.Result().Value())
+			}
+			for k, v := range m {
+				buildArgs[k] = v
+			}
+		}
+		if err := templateValues(ctx, res, opts.BuildArgs, buildArgs); err != nil {
+			return nil, err
+		}
+		args = append(args, pairs("--build-arg", buildArgs)...)
+
+		labels := make(map[string]string, len(opts.Labels))
+		if err := templateValues(ctx, res, opts.Labels, labels); err != nil {
+			return nil, err
+		}
+		args = append(args, pairs("--label", labels)...)
+
+		args = append(args, dir)
+
+		if _, err := run(ctx, w, args...); err != nil {
+			return nil, err
+		}
+
+		/* #nosec */
+		id, err := ioutil.ReadFile(iid.Name())
+		if err != nil {
+			return nil, err
+		}
+		img := Image{ID: strings.TrimSpace(string(id)), Tags: tags}
+		if img.ID == "" {
+			return nil, fmt.Errorf("docker build did not report the image ID")
+		}
+		return img, nil
+	})
+}
+
+// Tag creates the target tag that refers to the source image, an empty source means the image produced by the previous task.
+// It produces Image with the target tag.
+func Tag(source, target string) *rosie.FnTask {
+	return rosie.DescribedFn("docker-tag", fmt.Sprintf("docker tag %s %s", orPrevious(source), target), func(ctx context.Context, w io.Writer, res rosie.Resulter) (interface{}, error) {
+		img, err := image(ctx, res, source)
+		if err != nil {
+			return nil, err
+		}
+		tags, err := templates(ctx, res, target)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := run(ctx, w, "tag", img.Ref(), tags[0]); err != nil {
+			return nil, err
+		}
+		return Image{ID: img.ID, Tags: tags}, nil
+	})
+}
+
+// Push pushes the image, an empty one means all tags of the image produced by the previous task.
+// It produces Image with digests reported by the registry.
+func Push(ref string) *rosie.FnTask {
+	return rosie.DescribedFn("docker-push", "docker push "+orPrevious(ref), func(ctx context.Context, w io.Writer, res rosie.Resulter) (interface{}, error) {
+		img, err := image(ctx, res, ref)
+		if err != nil {
+			return nil, err
+		}
+		if len(img.Tags) == 0 {
+			return nil, fmt.Errorf("image %s has no tags to push", img.ID)
+		}
+
+		img.Digests = make(map[string]string, len(img.Tags))
+		for _, tag := range img.Tags {
+			out, err := run(ctx, w, "push", tag)
+			if err != nil {
+				return nil, err
+			}
+			m := digest.FindStringSubmatch(out)
+			if m == nil {
+				return nil, fmt.Errorf("docker push did not report the digest of %s", tag)
+			}
+			img.Digests[tag] = m[1]
+		}
+		img.Digest = img.Digests[img.Tags[0]]
+		return img, nil
+	})
+}
+
+// Run runs a command in a new container and produces lines of its standard output as a []string.
+func Run(opts RunOpts) *rosie.FnTask {
+	desc := append([]string{"docker", "run", orPrevious(opts.Image)}, opts.Command...)
+
+	return rosie.DescribedFn("docker-run", strings.Join(desc, " "), func(ctx context.Context, w io.Writer, res rosie.Resulter) (interface{}, error) {
+		img, err := image(ctx, res, opts.Image)
+		if err != nil {
+			return nil, err
+		}
+
+		args := []string{"run", "--rm"}
+		if opts.Platform != "" {
+			args = append(args, "--platform", opts.Platform)
+		}
+		if opts.Workdir != "" {
+			args = append(args, "--workdir", opts.Workdir)
+		}
+		volumes, err := templates(ctx, res, opts.Volumes...)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range volumes {
+			args = append(args, "--volume", v)
+		}
+		env := make(map[string]string, len(opts.Env))
+		if err := templateValues(ctx, res, opts.Env, env); err != nil {
+			return nil, err
+		}
+		args = append(args, pairs("--env", env)...)
+
+		command, err := templates(ctx, res, opts.Command...)
+		if err != nil {
+			return nil, err
+		}
+		args = append(append(args, img.Ref()), command...)
+
+		out, err := run(ctx, w, args...)
+		if err != nil {
+			return nil, err
+		}
+		lines := []string{}
+		for _, l := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+			if l != "" {
+				lines = append(lines, l)
+			}
+		}
+		return lines, nil
+	})
+}
+
+var digest = regexp.MustCompile(`digest: (sha256:[0-9a-f]{64})`)
+
+// image returns the image given by the reference, or produced by the previous task if it is empty.
+func image(ctx context.Context, res rosie.Resulter, ref string) (Image, error) {
+	if ref != "" {
+		refs, err := templates(ctx, res, ref)
+		if err != nil {
+			return Image{}, err
+		}
+		return Image{ID: refs[0], Tags: refs}, nil
+	}
+
+	switch v := res.Result().Value().(type) {
+	case Image:
+		return v, nil
+	case *Image:
+		return *v, nil
+	case string:
+		return Image{ID: v, Tags: []string{v}}, nil
+	default:
+		return Image{}, fmt.Errorf("image expected, got %T", v)
+	}
+}
+
+// orPrevious returns the reference, or a placeholder of the image produced by the previous task if it is empty.
+func orPrevious(ref string) string {
+	if ref == "" {
+		return "<previous image>"
+	}
+	return ref
+}
+
+// repository strips the tag from the reference, e.g. registry:5000/app:v1 becomes registry:5000/app.
+func repository(ref string) string {
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i]
+	}
+	return ref
+}
+
+func templates(ctx context.Context, res rosie.Resulter, texts ...string) ([]string, error) {
+	out := make([]string, 0, len(texts))
+	for _, text := range texts {
+		s, err := rosie.ExecuteTemplate(ctx, text, res)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func templateValues(ctx context.Context, res rosie.Resulter, in, out map[string]string) error {
+	for k, v := range in {
+		s, err := rosie.ExecuteTemplate(ctx, v, res)
+		if err != nil {
+			return err
+		}
+		out[k] = s
+	}
+	return nil
+}
+
+// pairs returns the flag followed by KEY=VALUE for each entry, sorted by keys.
+func pairs(flag string, m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	args := make([]string, 0, 2*len(keys))
+	for _, k := range keys {
+		args = append(args, flag, k+"="+m[k])
+	}
+	return args
+}
+
+// run runs docker with the arguments through the commander carried by the context.
+// The output is written to w, the standard output is returned as well.
+func run(ctx context.Context, w io.Writer, args ...string) (string, error) {
+	if _, err := fmt.Fprintf(w, "docker %s\n", strings.Join(args, " ")); err != nil {
+		return "", err
+	}
+
+	out := syncio.NewWriter(w)
+	stdout := bytes.NewBuffer(nil)
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Env = os.Environ()
+	cmd.Stdout = io.MultiWriter(out, stdout)
+	cmd.Stderr = out
+
+	proc, err := rosie.CommanderFrom(ctx).Start(ctx, cmd)
+	if err != nil {
+		return "", err
+	}
+	if _, err := proc.Wait(); err != nil {
+		return "", err
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,200 @@
+package docker_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/runner/testrunner"
+	"github.com/travelaudience/rosie/pkg/tasks/docker"
+)
+
+const (
+	imageID = "sha256:4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+	digest  = "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	// mirrorDigest is reported for tags pushed to the mirror.
+	mirrorDigest = "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+)
+
+// fakeDocker puts a docker script on PATH that logs its arguments and responds like the real binary would.
+func fakeDocker(t *testing.T) (log string, cleanup func()) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake docker binary is a shell script")
+	}
+
+	dir, err := ioutil.TempDir("", "rosie-docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log = filepath.Join(dir, "calls.log")
+	script := `#!/bin/sh
+echo "$@" >> "` + log + `"
+case "$1" in
+build)
+	while [ $# -gt 0 ]; do
+		if [ "$1" = "--iidfile" ]; then echo "` + imageID + `" > "$2"; fi
+		shift
+	done
+	echo "Step 1/1 : FROM scratch" ;;
+push)
+	if [ "$2" = "private/app:v1" ]; then echo "denied: requested access to the resource is denied" >&2; exit 1; fi
+	if [ "$2" = "mirror.example.com/app:v1" ]; then echo "v1: digest: ` + mirrorDigest + ` size: 528"; exit 0; fi
+	echo "v1: digest: ` + digest + ` size: 528" ;;
+run)
+	echo "hello from container" ;;
+esac
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	path := os.Getenv("PATH")
+	if err := os.Setenv("PATH", dir+string(os.PathListSeparator)+path); err != nil {
+		t.Fatal(err)
+	}
+	return log, func() {
+		_ = os.Setenv("PATH", path)
+		_ = os.RemoveAll(dir)
+	}
+}
+
+var iidfile = regexp.MustCompile(`--iidfile \S+ `)
+
+func calls(t *testing.T, log string) []string {
+	t.Helper()
+
+	buf, err := ioutil.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(buf)), "\n")
+}
+
+func TestBuildTagPushRun(t *testing.T) {
+	log, cleanup := fakeDocker(t)
+	defer cleanup()
+
+	g := rosie.Group("release")
+	g.Beginning().
+		Then(rosie.Fn("version", func(_ context.Context, _ io.Writer, _ rosie.Resulter) (interface{}, error) {
+			return "v1", nil
+		})).
+		Then(docker.Build(docker.BuildOpts{
+			Context:   "./app",
+			Tags:      []string{"app:[[.Result.Value]]"},
+			BuildArgs: map[string]string{"VERSION": "[[.Result.Value]]", "APP": "[[.Params.app]]"},
+			Labels:    map[string]string{"org.opencontainers.image.version": "[[.Result.Value]]"},
+			Platform:  "linux/amd64",
+		})).
+		Then(docker.Tag("", "registry.example.com/[[.Params.app]]:v1")).
+		Then(docker.Push("")).
+		Then(docker.Run(docker.RunOpts{
+			Command: []string{"version"},
+			Env:     map[string]string{"DEBUG": "1"},
+		}))
+
+	ctx := rosie.WithParams(context.Background(), map[string]interface{}{"app": "rosie"})
+	rec := testrunner.ExecuteContext(ctx, t, g)
+	if rec.Err != nil {
+		t.Fatal(rec.Err)
+	}
+
+	testrunner.AssertResult(t, rec, "docker-build", docker.Image{ID: imageID, Tags: []string{"app:v1"}})
+	testrunner.AssertResult(t, rec, "docker-tag", docker.Image{ID: imageID, Tags: []string{"registry.example.com/rosie:v1"}})
+	testrunner.AssertResult(t, rec, "docker-push", docker.Image{
+		ID:      imageID,
+		Tags:    []string{"registry.example.com/rosie:v1"},
+		Digest:  digest,
+		Digests: map[string]string{"registry.example.com/rosie:v1": digest},
+	})
+	testrunner.AssertResult(t, rec, "docker-run", []string{"hello from container"})
+	testrunner.AssertOutputContains(t, rec, "docker-build", "Step 1/1")
+
+	if desc := rec.Task("docker-tag").Description; desc != "docker tag <previous image> registry.example.com/[[.Params.app]]:v1" {
+		t.Errorf("unexpected description: %s", desc)
+	}
+
+	got := calls(t, log)
+	exp := []string{
+		"build --iidfile * --tag app:v1 --platform linux/amd64 --build-arg APP=rosie --build-arg VERSION=v1 --label org.opencontainers.image.version=v1 ./app",
+		"tag app:v1 registry.example.com/rosie:v1",
+		"push registry.example.com/rosie:v1",
+		"run --rm --env DEBUG=1 registry.example.com/rosie@" + digest + " version",
+	}
+	if len(got) != len(exp) {
+		t.Fatalf("unexpected calls:\n%s", strings.Join(got, "\n"))
+	}
+	for i := range exp {
+		if call := iidfile.ReplaceAllString(got[i], "--iidfile * "); call != exp[i] {
+			t.Errorf("unexpected call %d:\n%s\nexpected:\n%s", i+1, got[i], exp[i])
+		}
+	}
+}
+
+func TestPush_allTags(t *testing.T) {
+	_, cleanup := fakeDocker(t)
+	defer cleanup()
+
+	tags := []string{"registry.example.com/app:v1", "mirror.example.com/app:v1"}
+	g := rosie.Group("release")
+	g.Beginning().
+		Then(rosie.Fn("image", func(_ context.Context, _ io.Writer, _ rosie.Resulter) (interface{}, error) {
+			return docker.Image{ID: imageID, Tags: tags}, nil
+		})).
+		Then(docker.Push(""))
+
+	rec := testrunner.Execute(t, g)
+	if rec.Err != nil {
+		t.Fatal(rec.Err)
+	}
+	testrunner.AssertResult(t, rec, "docker-push", docker.Image{
+		ID:      imageID,
+		Tags:    tags,
+		Digest:  digest,
+		Digests: map[string]string{tags[0]: digest, tags[1]: mirrorDigest},
+	})
+}
+
+func TestPush_failure(t *testing.T) {
+	_, cleanup := fakeDocker(t)
+	defer cleanup()
+
+	g := rosie.Group("release")
+	g.Beginning().
+		Then(docker.Push("private/app:v1")).
+		Then(docker.Run(docker.RunOpts{}))
+
+	rec := testrunner.Execute(t, g)
+	if rec.Err == nil {
+		t.Fatal("error expected")
+	}
+	testrunner.AssertFailed(t, rec, "docker-push")
+	testrunner.AssertOutputContains(t, rec, "docker-push", "access to the resource is denied")
+	testrunner.AssertSkipped(t, rec, "docker-run")
+}
+
+func TestBuild_resultArgs(t *testing.T) {
+	fake := &testrunner.FakeCommander{}
+	fake.On("docker", "build", "...")
+
+	g := rosie.Group("release")
+	g.Beginning().
+		Then(rosie.Fn("args", func(_ context.Context, _ io.Writer, _ rosie.Resulter) (interface{}, error) {
+			return map[string]string{"GO_VERSION": "1.12"}, nil
+		})).
+		Then(docker.Build(docker.BuildOpts{ResultArgs: true, Target: "release"}))
+
+	rec := testrunner.ExecuteContext(rosie.WithCommander(context.Background(), fake), t, g)
+	if rec.Err == nil {
+		t.Fatal("error expected, the fake does not write the image ID")
+	}
+	fake.AssertCalled(t, "docker", "build", "--iidfile", "*", "--target", "release", "--build-arg", "GO_VERSION=1.12", ".")
+}
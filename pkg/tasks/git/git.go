@@ -0,0 +1,151 @@
+// Package git provides tasks that run the git binary (through rosie.CmdTask) and produce typed results,
+// e.g. a []string of changed files ready for rosie.ForEach:
+//
+//	g.Beginning().
+//		Then(git.ChangedFiles("origin/master")).
+//		Then(rosie.ForEach("lint", func(path string) rosie.Attacher { ... }))
+//
+// Arguments are templates, like commands of rosie.Cmd, e.g. git.ChangedFiles("[[.Params.base]]").
+// Each task is a group of the command and a step that parses its output, the result of the group is the parsed value.
+// ChangedFiles combines the output of two commands, it runs them in a single step through rosie.CommanderFrom,
+// so it can be tested with testrunner.FakeCommander as well.
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/internal/syncio"
+)
+
+// quotePath makes git print paths as they are, even if they contain special characters.
+var quotePath = []string{"-c", "core.quotePath=false"}
+
+// Commit produces the hash of the current commit as a string.
+func Commit() *rosie.GroupTask {
+	return command("git-commit", firstLine, "rev-parse", "HEAD")
+}
+
+// CurrentRef produces the name of the current branch as a string, or HEAD if it is detached.
+func CurrentRef() *rosie.GroupTask {
+	return command("git-current-ref", firstLine, "rev-parse", "--abbrev-ref", "HEAD")
+}
+
+// IsDirty produces true if the working tree has any changes, including untracked files.
+func IsDirty() *rosie.GroupTask {
+	return command("git-is-dirty", func(lines []string) interface{} {
+		return len(nonEmpty(lines)) > 0
+	}, "status", "--porcelain")
+}
+
+// ChangedFiles produces a []string of files that were added, copied, modified or renamed since the base ref,
+// including changes that are not committed yet and untracked files that are not ignored. Deleted files are left out.
+func ChangedFiles(base string) *rosie.GroupTask {
+	return rosie.Group("git-changed-files",
+		rosie.DescribedFn("git-diff", "changed files since "+base, func(ctx context.Context, w io.Writer, res rosie.Resulter) (interface{}, error) {
+			ref, err := rosie.ExecuteTemplate(ctx, base, res)
+			if err != nil {
+				return nil, err
+			}
+			changed, err := run(ctx, w, "diff", "--name-only", "--diff-filter=ACMR", ref)
+			if err != nil {
+				return nil, err
+			}
+			untracked, err := run(ctx, w, "ls-files", "--others", "--exclude-standard")
+			if err != nil {
+				return nil, err
+			}
+			return append(nonEmpty(changed), nonEmpty(untracked)...), nil
+		}),
+	)
+}
+
+// LsFiles produces a []string of tracked files matching the pathspec, e.g. *.go.
+func LsFiles(pattern string) *rosie.GroupTask {
+	return command("git-ls-files", paths, "ls-files", "--", pattern)
+}
+
+// Tags produces a []string of tags matching the pattern (e.g. v1.*), sorted by version.
+func Tags(pattern string) *rosie.GroupTask {
+	return command("git-tags", paths, "tag", "--list", "--sort=version:refname", pattern)
+}
+
+// Tag creates a lightweight tag pointing to the current commit, it produces the name of the tag.
+func Tag(name string) *rosie.GroupTask {
+	return rosie.Group("git-tag",
+		cmd("tag", name),
+		cmd("describe", "--tags", "--exact-match", name),
+		rosie.Fn("parse", rosie.StringSliceClosure(func(_ context.Context, _ io.Writer, lines []string) (interface{}, error) {
+			return firstLine(lines), nil
+		})),
+	)
+}
+
+// command creates a group that runs git with the arguments and parses lines of its output.
+func command(name string, parse func([]string) interface{}, args ...string) *rosie.GroupTask {
+	return rosie.Group(name,
+		cmd(args...),
+		rosie.Fn("parse", rosie.StringSliceClosure(func(_ context.Context, _ io.Writer, lines []string) (interface{}, error) {
+			return parse(lines), nil
+		})),
+	)
+}
+
+// cmd runs git with the arguments, paths in the output are not quoted even if they contain special characters.
+func cmd(args ...string) *rosie.CmdTask {
+	return rosie.Cmd("git-"+args[0], append(append([]string{"git"}, quotePath...), args...)...)
+}
+
+// run runs git with the arguments through the commander carried by the context, like cmd does.
+// The output is written to w, lines of the standard output are returned as well.
+func run(ctx context.Context, w io.Writer, args ...string) ([]string, error) {
+	args = append(append([]string(nil), quotePath...), args...)
+	if _, err := fmt.Fprintf(w, "git %s\n", strings.Join(args, " ")); err != nil {
+		return nil, err
+	}
+
+	out := syncio.NewWriter(w)
+	stdout := bytes.NewBuffer(nil)
+	/* #nosec */
+	c := exec.CommandContext(ctx, "git", args...)
+	c.Env = os.Environ()
+	c.Stdout = io.MultiWriter(out, stdout)
+	c.Stderr = out
+
+	proc, err := rosie.CommanderFrom(ctx).Start(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := proc.Wait(); err != nil {
+		return nil, err
+	}
+	return strings.Split(stdout.String(), "\n"), nil
+}
+
+func firstLine(lines []string) interface{} {
+	if lines := nonEmpty(lines); len(lines) > 0 {
+		return lines[0]
+	}
+	return ""
+}
+
+func paths(lines []string) interface{} {
+	return nonEmpty(lines)
+}
+
+// nonEmpty returns trimmed lines that are not blank, it never returns nil.
+func nonEmpty(lines []string) []string {
+	res := make([]string, 0, len(lines))
+	for _, l := range lines {
+		if l = strings.TrimSpace(l); l != "" {
+			res = append(res, l)
+		}
+	}
+	return res
+}
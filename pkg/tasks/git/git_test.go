@@ -0,0 +1,91 @@
+package git_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/runner/testrunner"
+	"github.com/travelaudience/rosie/pkg/tasks/git"
+)
+
+func TestTasks(t *testing.T) {
+	fake := &testrunner.FakeCommander{}
+	fake.On("git", "-c", "core.quotePath=false", "rev-parse", "HEAD").Stdout("3f2a1b\n")
+	fake.On("git", "-c", "core.quotePath=false", "rev-parse", "--abbrev-ref", "HEAD").Stdout("master")
+	fake.On("git", "-c", "core.quotePath=false", "status", "--porcelain").Stdout(" M go.mod\n?? new.go")
+	fake.On("git", "-c", "core.quotePath=false", "diff", "--name-only", "--diff-filter=ACMR", "origin/master").Stdout("cmd.go\npkg/dag/dag.go\n")
+	fake.On("git", "-c", "core.quotePath=false", "ls-files", "--others", "--exclude-standard").Stdout("new dir/ünïcode.go\n")
+	fake.On("git", "-c", "core.quotePath=false", "ls-files", "--", "*.md").Stdout("")
+	fake.On("git", "-c", "core.quotePath=false", "tag", "--list", "--sort=version:refname", "v1.*").Stdout("v1.0.0\nv1.1.0")
+	fake.On("git", "-c", "core.quotePath=false", "tag", "v1.2.0")
+	fake.On("git", "-c", "core.quotePath=false", "describe", "--tags", "--exact-match", "v1.2.0").Stdout("v1.2.0")
+
+	cases := map[string]struct {
+		task *rosie.GroupTask
+		exp  interface{}
+	}{
+		"commit":        {task: git.Commit(), exp: "3f2a1b"},
+		"current-ref":   {task: git.CurrentRef(), exp: "master"},
+		"is-dirty":      {task: git.IsDirty(), exp: true},
+		"changed-files": {task: git.ChangedFiles("[[.Params.base]]"), exp: []string{"cmd.go", "pkg/dag/dag.go", "new dir/ünïcode.go"}},
+		"ls-files":      {task: git.LsFiles("*.md"), exp: []string{}},
+		"tags":          {task: git.Tags("v1.*"), exp: []string{"v1.0.0", "v1.1.0"}},
+		"tag":           {task: git.Tag("v1.2.0"), exp: "v1.2.0"},
+	}
+
+	for hint, c := range cases {
+		t.Run(hint, func(t *testing.T) {
+			g := rosie.Group("git")
+			g.Beginning().
+				Then(c.task).
+				Then(rosie.Fn("result", func(_ context.Context, _ io.Writer, res rosie.Resulter) (interface{}, error) {
+					return res.Result().Value(), nil
+				}))
+
+			ctx := rosie.WithParams(rosie.WithCommander(context.Background(), fake), map[string]interface{}{"base": "origin/master"})
+			rec := testrunner.ExecuteContext(ctx, t, g)
+			if rec.Err != nil {
+				t.Fatal(rec.Err)
+			}
+			testrunner.AssertResult(t, rec, "result", c.exp)
+		})
+	}
+}
+
+func TestTasks_failure(t *testing.T) {
+	fake := &testrunner.FakeCommander{}
+	fake.On("git", "...").Stderr("fatal: not a git repository").ExitCode(128)
+
+	g := rosie.Group("git")
+	g.Beginning().Then(git.ChangedFiles("master"))
+
+	rec := testrunner.ExecuteContext(rosie.WithCommander(context.Background(), fake), t, g)
+	if rec.Err == nil {
+		t.Fatal("error expected")
+	}
+	testrunner.AssertFailed(t, rec, "git-diff")
+	testrunner.AssertOutputContains(t, rec, "git-diff", "not a git repository")
+	fake.AssertNotCalled(t, "git", "-c", "core.quotePath=false", "ls-files", "...")
+}
+
+func TestChangedFiles_clone(t *testing.T) {
+	fake := &testrunner.FakeCommander{}
+	fake.On("git", "-c", "core.quotePath=false", "diff", "--name-only", "--diff-filter=ACMR", "master").Stdout("cmd.go\n")
+	fake.On("git", "-c", "core.quotePath=false", "ls-files", "--others", "--exclude-standard").Stdout("new.go\n")
+
+	changed := git.ChangedFiles("master")
+	g := rosie.Group("git")
+	g.Beginning().
+		Then(changed.Clone()).
+		Then(rosie.Fn("result", func(_ context.Context, _ io.Writer, res rosie.Resulter) (interface{}, error) {
+			return res.Result().Value(), nil
+		}))
+
+	rec := testrunner.ExecuteContext(rosie.WithCommander(context.Background(), fake), t, g)
+	if rec.Err != nil {
+		t.Fatal(rec.Err)
+	}
+	testrunner.AssertResult(t, rec, "result", []string{"cmd.go", "new.go"})
+}
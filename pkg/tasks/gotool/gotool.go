@@ -0,0 +1,165 @@
+// Package gotool provides tasks for common Go CI flows, e.g. building main packages and running tests:
+//
+//	g.Beginning().
+//		Then(gotool.ModTidyCheck(".")).
+//		Then(gotool.Vet("./...")).
+//		Then(gotool.Test("./...")).
+//		Then(gotool.MainPackages("./cmd/...")).
+//		Then(rosie.ForEach("go-build", func(key string) rosie.Attacher {
+//			return rosie.Cmd(key, "go", "build", "-o", "./bin/[[.Result.Value.Name]]", "[[.Result.Value.ImportPath]]")
+//		}))
+//
+// Programs are started through rosie.CommanderFrom, so the tasks can be tested with testrunner.FakeCommander.
+package gotool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/travelaudience/rosie"
+)
+
+// Package is a package reported by go list -json.
+type Package struct {
+	Dir          string
+	ImportPath   string
+	Name         string
+	Doc          string
+	GoFiles      []string
+	TestGoFiles  []string
+	XTestGoFiles []string
+	Imports      []string
+}
+
+// Main reports whether the package is a command.
+func (p Package) Main() bool {
+	return p.Name == "main"
+}
+
+// ListPackages produces a []Package of packages matching the pattern, e.g. ./cmd/....
+func ListPackages(pattern string) *rosie.GroupTask {
+	return rosie.Group("go-list-packages",
+		rosie.Cmd("go-list", "go", "list", "-json", pattern),
+		rosie.Fn("parse", rosie.StringSliceClosure(func(_ context.Context, _ io.Writer, lines []string) (interface{}, error) {
+			pkgs := []Package{}
+			dec := json.NewDecoder(strings.NewReader(strings.Join(lines, "\n")))
+			for dec.More() {
+				var pkg Package
+				if err := dec.Decode(&pkg); err != nil {
+					return nil, err
+				}
+				pkgs = append(pkgs, pkg)
+			}
+			return pkgs, nil
+		})),
+	)
+}
+
+// MainPackages produces a []Package of commands matching the pattern.
+func MainPackages(pattern string) *rosie.GroupTask {
+	g := rosie.Group("go-main-packages")
+	g.Beginning().
+		Then(ListPackages(pattern)).
+		Then(rosie.Transform("only-main", func(_ context.Context, _ io.Writer, res rosie.Resulter) (interface{}, error) {
+			if pkg, ok := res.Result().Value().(Package); ok && pkg.Main() {
+				return pkg, nil
+			}
+			return rosie.Nothing, nil
+		}))
+	return g
+}
+
+// Build compiles the packages, ./... if none are given.
+func Build(packages ...string) *rosie.CmdTask {
+	return rosie.Cmd("go-build", append([]string{"go", "build"}, orAll(packages)...)...)
+}
+
+// Vet runs go vet on the packages, ./... if none are given.
+func Vet(packages ...string) *rosie.CmdTask {
+	return rosie.Cmd("go-vet", append([]string{"go", "vet"}, orAll(packages)...)...)
+}
+
+// ModTidyCheck fails if go mod tidy would change go.mod or go.sum of the module in the directory.
+// The module is left untouched, go mod tidy works on copies of both files (it requires Go 1.14 or newer).
+func ModTidyCheck(dir string) *rosie.FnTask {
+	return rosie.DescribedFn("go-mod-tidy-check", "go mod tidy (check) in "+dir, func(ctx context.Context, w io.Writer, _ rosie.Resulter) (interface{}, error) {
+		tmp, err := ioutil.TempDir("", "rosie-mod-tidy-")
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = os.RemoveAll(tmp) }()
+
+		files := []string{"go.mod", "go.sum"}
+		before := make(map[string][]byte, len(files))
+		for _, f := range files {
+			/* #nosec */
+			buf, err := ioutil.ReadFile(filepath.Join(dir, f))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			before[f] = buf
+			if err := ioutil.WriteFile(filepath.Join(tmp, f), buf, 0600); err != nil {
+				return nil, err
+			}
+		}
+
+		cmd := exec.CommandContext(ctx, "go", "mod", "tidy", "-modfile="+filepath.Join(tmp, "go.mod"))
+		cmd.Dir = dir
+		if err := run(ctx, w, cmd); err != nil {
+			return nil, err
+		}
+
+		var untidy []string
+		for _, f := range files {
+			/* #nosec */
+			buf, err := ioutil.ReadFile(filepath.Join(tmp, f))
+			if err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+			if !bytes.Equal(buf, before[f]) {
+				untidy = append(untidy, f)
+			}
+		}
+		if len(untidy) > 0 {
+			return nil, fmt.Errorf("%s not tidy, run go mod tidy", strings.Join(untidy, " and "))
+		}
+		if _, err := fmt.Fprintln(w, "go.mod and go.sum are tidy"); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	})
+}
+
+// run starts the command through the commander carried by the context, the output is written to w.
+func run(ctx context.Context, w io.Writer, cmd *exec.Cmd) error {
+	cmd.Env = os.Environ()
+	if cmd.Stdout == nil {
+		cmd.Stdout = w
+	}
+	cmd.Stderr = w
+
+	proc, err := rosie.CommanderFrom(ctx).Start(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	_, err = proc.Wait()
+	return err
+}
+
+func orAll(packages []string) []string {
+	if len(packages) == 0 {
+		return []string{"./..."}
+	}
+	return packages
+}
@@ -0,0 +1,166 @@
+package gotool_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/runner/testrunner"
+	"github.com/travelaudience/rosie/pkg/tasks/gotool"
+)
+
+const goList = `{
+	"Dir": "/src/cmd/rosie",
+	"ImportPath": "example.com/cmd/rosie",
+	"Name": "main",
+	"GoFiles": ["main.go"]
+}
+{
+	"Dir": "/src/cmd/internal",
+	"ImportPath": "example.com/cmd/internal",
+	"Name": "internal"
+}
+`
+
+func TestListPackages(t *testing.T) {
+	fake := &testrunner.FakeCommander{}
+	fake.On("go", "list", "-json", "./cmd/...").Stdout(goList)
+
+	g := rosie.Group("go")
+	g.Beginning().
+		Then(gotool.MainPackages("./cmd/...")).
+		Then(rosie.ForEach("go-build", func(key string) rosie.Attacher {
+			return rosie.Cmd(key, "go", "build", "-o", "./bin/[[.Result.Value.Name]]", "[[.Result.Value.ImportPath]]")
+		}))
+
+	fake.On("go", "build", "...")
+	rec := testrunner.ExecuteContext(rosie.WithCommander(context.Background(), fake), t, g)
+	if rec.Err != nil {
+		t.Fatal(rec.Err)
+	}
+	testrunner.AssertResult(t, rec, "parse", []gotool.Package{
+		{Dir: "/src/cmd/rosie", ImportPath: "example.com/cmd/rosie", Name: "main", GoFiles: []string{"main.go"}},
+		{Dir: "/src/cmd/internal", ImportPath: "example.com/cmd/internal", Name: "internal"},
+	})
+	fake.AssertCalled(t, "go", "build", "-o", "./bin/main", "example.com/cmd/rosie")
+	fake.AssertNotCalled(t, "go", "build", "-o", "*", "example.com/cmd/internal")
+}
+
+func TestTest(t *testing.T) {
+	fake := &testrunner.FakeCommander{}
+	fake.On("go", "test", "-json", "./...").
+		Stdout(`{"Action":"run","Package":"example.com/a","Test":"TestA"}
+{"Action":"output","Package":"example.com/a","Test":"TestA","Output":"=== RUN   TestA\n"}
+{"Action":"output","Package":"example.com/a","Test":"TestA","Output":"    a_test.go:10: broken\n"}
+{"Action":"fail","Package":"example.com/a","Test":"TestA","Elapsed":0.5}
+{"Action":"run","Package":"example.com/a","Test":"TestB"}
+{"Action":"skip","Package":"example.com/a","Test":"TestB","Elapsed":0}
+{"Action":"fail","Package":"example.com/a","Elapsed":0.6}
+{"Action":"pass","Package":"example.com/b","Elapsed":0.1}`).
+		Stderr("# example.com/c\nc.go:1: syntax error").
+		ExitCode(1)
+
+	g := rosie.Group("go")
+	g.Beginning().Then(gotool.Test())
+
+	rec := testrunner.ExecuteContext(rosie.WithCommander(context.Background(), fake), t, g)
+	terr, ok := rec.Err.(*gotool.TestError)
+	if !ok {
+		t.Fatalf("unexpected error: %#v", rec.Err)
+	}
+	if exp := "2 failed: example.com/a.TestA, example.com/a"; terr.Error() != exp {
+		t.Errorf("unexpected error: %s", terr)
+	}
+	if exp := (gotool.TestResult{
+		Package: "example.com/a",
+		Test:    "TestA",
+		Action:  gotool.ActionFail,
+		Elapsed: 500 * time.Millisecond,
+		Output:  []string{"=== RUN   TestA", "    a_test.go:10: broken"},
+	}); !reflect.DeepEqual(terr.Failed[0], exp) {
+		t.Errorf("unexpected result: %#v", terr.Failed[0])
+	}
+	testrunner.AssertFailed(t, rec, "go-test")
+	testrunner.AssertOutputContains(t, rec, "go-test", "a_test.go:10: broken")
+	testrunner.AssertOutputContains(t, rec, "go-test", "syntax error")
+	if desc := rec.Task("go-test").Description; desc != "go test -json ./..." {
+		t.Errorf("unexpected description: %s", desc)
+	}
+}
+
+func TestModTidyCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rosie-gotool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	mod := filepath.Join(dir, "go.mod")
+	if err := ioutil.WriteFile(mod, []byte("module example.com/untidy\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := rosie.Group("go")
+	g.Beginning().Then(gotool.ModTidyCheck(dir))
+
+	rec := testrunner.Execute(t, g)
+	if rec.Err == nil {
+		t.Fatal("error expected")
+	}
+	testrunner.AssertFailed(t, rec, "go-mod-tidy-check")
+
+	buf, err := ioutil.ReadFile(mod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "module example.com/untidy\n" {
+		t.Errorf("go.mod should not be modified, got:\n%s", buf)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.sum")); !os.IsNotExist(err) {
+		t.Errorf("go.sum should not exist: %v", err)
+	}
+
+	// Nothing changes if go mod tidy does not touch the files.
+	fake := &testrunner.FakeCommander{}
+	fake.On("go", "mod", "tidy", "...")
+
+	g = rosie.Group("go")
+	g.Beginning().Then(gotool.ModTidyCheck(dir))
+
+	rec = testrunner.ExecuteContext(rosie.WithCommander(context.Background(), fake), t, g)
+	if rec.Err != nil {
+		t.Fatal(rec.Err)
+	}
+	if calls := fake.Called("go", "mod", "tidy", "..."); len(calls) != 1 || calls[0].Dir != dir {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+	if desc := rec.Task("go-mod-tidy-check").Description; desc != "go mod tidy (check) in "+dir {
+		t.Errorf("unexpected description: %s", desc)
+	}
+}
+
+func TestBuildAndVet(t *testing.T) {
+	fake := &testrunner.FakeCommander{}
+	fake.On("go", "...")
+
+	g := rosie.Group("go")
+	g.Beginning().
+		Then(gotool.Vet()).
+		Then(gotool.Build("./cmd/..."))
+
+	rec := testrunner.ExecuteContext(rosie.WithCommander(context.Background(), fake), t, g)
+	if rec.Err != nil {
+		t.Fatal(rec.Err)
+	}
+	testrunner.AssertOrder(t, rec, "go-vet", "go-build")
+	fake.AssertCalled(t, "go", "vet", "./...")
+	fake.AssertCalled(t, "go", "build", "./cmd/...")
+}
@@ -0,0 +1,151 @@
+package gotool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/internal/syncio"
+)
+
+// Test actions reported by go test -json.
+const (
+	ActionPass = "pass"
+	ActionFail = "fail"
+	ActionSkip = "skip"
+)
+
+// TestResult is the outcome of a single test, or of a whole package if Test is empty.
+type TestResult struct {
+	Package string
+	Test    string
+	// Action is one of ActionPass, ActionFail or ActionSkip.
+	Action  string
+	Elapsed time.Duration
+	Output  []string
+}
+
+// TestError is returned by Test if any of the tests or packages failed.
+type TestError struct {
+	Failed []TestResult
+}
+
+// Error implements error interface.
+func (e *TestError) Error() string {
+	names := make([]string, 0, len(e.Failed))
+	for _, r := range e.Failed {
+		if r.Test == "" {
+			names = append(names, r.Package)
+		} else {
+			names = append(names, r.Package+"."+r.Test)
+		}
+	}
+	return fmt.Sprintf("%d failed: %s", len(e.Failed), strings.Join(names, ", "))
+}
+
+// Test runs go test -json on the packages (./... if none are given) and produces a []TestResult, in the order tests completed.
+// The output of tests is passed on as it is, so it reads the same as go test -v.
+// If anything failed, the results are still produced along with *TestError.
+func Test(packages ...string) *rosie.FnTask {
+	args := append([]string{"test", "-json"}, orAll(packages)...)
+
+	return rosie.DescribedFn("go-test", "go "+strings.Join(args, " "), func(ctx context.Context, w io.Writer, _ rosie.Resulter) (interface{}, error) {
+		out := syncio.NewWriter(w)
+		events := &eventWriter{out: out, running: make(map[string]*TestResult)}
+
+		cmd := exec.CommandContext(ctx, "go", args...)
+		cmd.Stdout = events
+		runErr := run(ctx, out, cmd)
+		events.flush()
+
+		var failed []TestResult
+		for _, r := range events.results {
+			if r.Action == ActionFail {
+				failed = append(failed, r)
+			}
+		}
+		switch {
+		case len(failed) > 0:
+			return events.results, &TestError{Failed: failed}
+		case runErr != nil:
+			return events.results, runErr
+		}
+		return events.results, nil
+	})
+}
+
+// testEvent is a line printed by go test -json, see go doc test2json.
+type testEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// eventWriter parses events written by go test -json line by line.
+type eventWriter struct {
+	out     io.Writer
+	buf     []byte
+	running map[string]*TestResult
+	results []TestResult
+}
+
+// Write implements io.Writer interface.
+func (e *eventWriter) Write(p []byte) (int, error) {
+	e.buf = append(e.buf, p...)
+	for {
+		i := bytes.IndexByte(e.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := e.buf[:i]
+		e.buf = e.buf[i+1:]
+		if err := e.line(line); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+func (e *eventWriter) flush() {
+	if len(e.buf) > 0 {
+		_ = e.line(e.buf)
+		e.buf = nil
+	}
+}
+
+func (e *eventWriter) line(line []byte) error {
+	var ev testEvent
+	if err := json.Unmarshal(line, &ev); err != nil || ev.Action == "" {
+		// Not an event, e.g. a build failure.
+		_, err := fmt.Fprintf(e.out, "%s\n", line)
+		return err
+	}
+
+	key := ev.Package + "\x00" + ev.Test
+	r, ok := e.running[key]
+	if !ok {
+		r = &TestResult{Package: ev.Package, Test: ev.Test}
+		e.running[key] = r
+	}
+
+	switch ev.Action {
+	case "output":
+		text := strings.TrimSuffix(ev.Output, "\n")
+		r.Output = append(r.Output, text)
+		_, err := fmt.Fprintf(e.out, "%s\n", text)
+		return err
+	case ActionPass, ActionFail, ActionSkip:
+		r.Action = ev.Action
+		r.Elapsed = time.Duration(ev.Elapsed * float64(time.Second))
+		e.results = append(e.results, *r)
+		delete(e.running, key)
+	}
+	return nil
+}
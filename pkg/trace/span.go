@@ -0,0 +1,142 @@
+// Package trace records the execution of workflows as OpenTelemetry-style traces.
+//
+// The whole run (the outermost group) is the root span, each nested group (including ForEach) is a child span
+// and each task is a leaf span of the innermost group it belongs to.
+// Spans are handed over to an Exporter once the run is completed.
+//
+// The span of a task is available inside its closure through the context:
+//
+//	span := trace.SpanFromContext(ctx)
+//	span.SetAttribute("files", len(files))
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Attributes set on spans by the tracer.
+const (
+	AttributeTaskID   = "rosie.task.id"
+	AttributeTaskPath = "rosie.task.path"
+	AttributeCommand  = "rosie.command"
+	AttributeDir      = "rosie.dir"
+	AttributeExitCode = "rosie.exit_code"
+	AttributeError    = "error"
+)
+
+// SpanData is a snapshot of a span, as passed to exporters.
+type SpanData struct {
+	// TraceID, SpanID and ParentSpanID are hex encoded, the parent is empty for the root span.
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Name         string
+	Start, End   time.Time
+	Attributes   map[string]interface{}
+	// Err is set if the operation the span describes failed.
+	Err error
+}
+
+// Span describes a single operation, it is safe for concurrent use.
+type Span struct {
+	tracer *Tracer
+	data   SpanData
+	ended  bool
+	lock   sync.Mutex
+}
+
+// SetAttribute sets an attribute, supported values are strings, booleans, integers and floats.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	s.data.Attributes[key] = value
+	s.lock.Unlock()
+}
+
+// SetError marks the span as failed.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.lock.Lock()
+	s.data.Err = err
+	s.lock.Unlock()
+}
+
+// End completes the span, subsequent calls have no effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	s.lock.Unlock()
+
+	s.tracer.finished(s)
+}
+
+// TraceID returns hex encoded identifier of the trace the span belongs to.
+func (s *Span) TraceID() string {
+	return s.data.TraceID
+}
+
+// SpanID returns hex encoded identifier of the span.
+func (s *Span) SpanID() string {
+	return s.data.SpanID
+}
+
+// Data returns a snapshot of the span.
+func (s *Span) Data() SpanData {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	data := s.data
+	data.Attributes = make(map[string]interface{}, len(s.data.Attributes))
+	for k, v := range s.data.Attributes {
+		data.Attributes[k] = v
+	}
+	return data
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a copy of the context that carries the span.
+func ContextWithSpan(ctx context.Context, s *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+// SpanFromContext returns the span carried by the context, or nil.
+// It is safe to call methods of a nil span.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// StartSpan starts a child of the span carried by the context, e.g. to measure a part of a task.
+// It returns nil span if the context does not carry any.
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+
+	s := parent.tracer.start(name, parent)
+	return ContextWithSpan(ctx, s), s
+}
+
+func newID(size int) string {
+	buf := make([]byte, size)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
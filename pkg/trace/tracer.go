@@ -0,0 +1,186 @@
+package trace
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/travelaudience/rosie/pkg/dag"
+	"github.com/travelaudience/rosie/pkg/runner"
+)
+
+var (
+	_ runner.Observer        = &Tracer{}
+	_ runner.ContextObserver = &Tracer{}
+)
+
+// Tracer records a run as a trace, it implements runner.Observer interface.
+type Tracer struct {
+	exporter Exporter
+	err      error
+
+	root   *Span
+	groups map[*dag.Node]*Span
+	tasks  map[string]*Span
+	done   []SpanData
+	lock   sync.Mutex
+}
+
+// NewTracer creates a tracer that exports spans once a run is completed.
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{
+		exporter: exporter,
+	}
+}
+
+// Err returns the error returned by the exporter during the last run, if any.
+func (t *Tracer) Err() error {
+	return t.err
+}
+
+// OnWorkflowStart implements runner.Observer interface.
+func (t *Tracer) OnWorkflowStart(wf *runner.Workflow) {
+	t.lock.Lock()
+	t.err = nil
+	t.groups = make(map[*dag.Node]*Span)
+	t.tasks = make(map[string]*Span)
+	t.done = nil
+	t.lock.Unlock()
+
+	t.root = t.start(wf.Name, nil)
+}
+
+// TaskContext implements runner.ContextObserver interface.
+// It starts a span for the task and passes it down to the task.
+func (t *Tracer) TaskContext(ctx context.Context, tsk *runner.Task) context.Context {
+	node := tsk.Joint.Node()
+	parent := t.parent(node)
+
+	var s *Span
+	switch tsk.Type {
+	case dag.TypeBeginning:
+		// The outermost group is the workflow itself.
+		s = parent
+		if parent != t.root {
+			s = t.start(tsk.Name, parent)
+		}
+		t.groups[node] = s
+	case dag.TypeMiddleBeginning:
+		s = t.start(tsk.Name, parent)
+		t.groups[node] = s
+	case dag.TypeEnd, dag.TypeMiddleEnd:
+		// The end of a group is a part of the group.
+		s = parent
+	default:
+		s = t.start(tsk.Name, parent)
+	}
+	s.SetAttribute(AttributeTaskPath, tsk.Path)
+	t.tasks[tsk.ID] = s
+
+	return ContextWithSpan(ctx, s)
+}
+
+// OnTaskStart implements runner.Observer interface.
+func (t *Tracer) OnTaskStart(tsk *runner.Task) {
+	s := t.tasks[tsk.ID]
+	if tsk.Type == dag.TypeMiddle {
+		s.SetAttribute(AttributeTaskID, tsk.ID)
+	}
+	if tsk.Description != "" {
+		s.SetAttribute(AttributeCommand, tsk.Description)
+	}
+}
+
+// OnOutput implements runner.Observer interface.
+func (t *Tracer) OnOutput(*runner.Task, string) {}
+
+// OnTaskEnd implements runner.Observer interface.
+func (t *Tracer) OnTaskEnd(tsk *runner.Task) {
+	s := t.tasks[tsk.ID]
+	if cmd, ok := tsk.Joint.(interface {
+		Dir() string
+		ExitCode() int
+	}); ok {
+		if dir := cmd.Dir(); dir != "" {
+			s.SetAttribute(AttributeDir, dir)
+		}
+		s.SetAttribute(AttributeExitCode, cmd.ExitCode())
+	}
+	if tsk.Err != nil {
+		s.SetError(tsk.Err)
+		s.SetAttribute(AttributeError, tsk.Err.Error())
+	}
+
+	switch {
+	case s == t.root:
+		// The root span ends with the workflow.
+	case tsk.Type == dag.TypeBeginning || tsk.Type == dag.TypeMiddleBeginning:
+		// Groups end together with their end nodes, unless the beginning failed.
+		if tsk.Err != nil {
+			s.End()
+		}
+	default:
+		s.End()
+	}
+}
+
+// OnWorkflowEnd implements runner.Observer interface.
+// Spans left open (e.g. because of a failure) are ended, and all of them are exported.
+func (t *Tracer) OnWorkflowEnd(wf *runner.Workflow) {
+	if wf.Err != nil {
+		t.root.SetError(wf.Err)
+		t.root.SetAttribute(AttributeError, wf.Err.Error())
+	}
+	for _, s := range t.groups {
+		s.End()
+	}
+	t.root.End()
+
+	t.lock.Lock()
+	spans := t.done
+	t.done = nil
+	t.lock.Unlock()
+
+	if t.exporter != nil {
+		t.err = t.exporter.Export(spans)
+	}
+}
+
+// parent returns the span of the innermost group the node belongs to.
+func (t *Tracer) parent(node *dag.Node) *Span {
+	scope := node.Scope()
+	for i := len(scope) - 1; i >= 0; i-- {
+		if s, ok := t.groups[scope[i]]; ok {
+			return s
+		}
+	}
+	return t.root
+}
+
+func (t *Tracer) start(name string, parent *Span) *Span {
+	s := &Span{
+		tracer: t,
+		data: SpanData{
+			SpanID:     newID(8),
+			Name:       name,
+			Start:      time.Now(),
+			Attributes: make(map[string]interface{}),
+		},
+	}
+	if parent != nil {
+		s.data.TraceID = parent.data.TraceID
+		s.data.ParentSpanID = parent.data.SpanID
+	} else {
+		s.data.TraceID = newID(16)
+	}
+
+	return s
+}
+
+func (t *Tracer) finished(s *Span) {
+	data := s.Data()
+
+	t.lock.Lock()
+	t.done = append(t.done, data)
+	t.lock.Unlock()
+}
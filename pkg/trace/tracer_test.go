@@ -0,0 +1,170 @@
+package trace_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/runner"
+	"github.com/travelaudience/rosie/pkg/trace"
+)
+
+func TestTracer(t *testing.T) {
+	inner := rosie.Group("test")
+	inner.Beginning().
+		Then(rosie.Fn("measure", func(ctx context.Context, _ io.Writer, _ rosie.Resulter) (interface{}, error) {
+			trace.SpanFromContext(ctx).SetAttribute("files", 3)
+			_, span := trace.StartSpan(ctx, "part")
+			span.End()
+			return nil, nil
+		})).
+		Then(rosie.Cmd("fail", "sh", "-c", "exit 3"))
+
+	g := rosie.Group("build")
+	g.Beginning().
+		Then(rosie.Cmd("echo", "echo", "hello")).
+		Then(inner)
+
+	exp := &trace.MemoryExporter{}
+	tr := trace.NewTracer(exp)
+	if err := runner.New(tr).Run(context.Background(), g); err == nil {
+		t.Fatal("error expected")
+	}
+	if tr.Err() != nil {
+		t.Fatalf("unexpected export error: %v", tr.Err())
+	}
+
+	spans := make(map[string]trace.SpanData)
+	for _, s := range exp.Spans() {
+		if _, ok := spans[s.Name]; ok {
+			t.Fatalf("duplicated span: %s", s.Name)
+		}
+		spans[s.Name] = s
+	}
+
+	parents := map[string]string{
+		"echo":    "build",
+		"test":    "build",
+		"measure": "test",
+		"part":    "measure",
+		"fail":    "test",
+	}
+	root := spans["build"]
+	if root.SpanID == "" || root.ParentSpanID != "" || root.Err == nil {
+		t.Fatalf("unexpected root span: %+v", root)
+	}
+	for name, parent := range parents {
+		s, ok := spans[name]
+		if !ok {
+			t.Errorf("missing span %s", name)
+			continue
+		}
+		if s.ParentSpanID != spans[parent].SpanID {
+			t.Errorf("%s should be a child of %s", name, parent)
+		}
+		if s.TraceID != root.TraceID {
+			t.Errorf("%s belongs to a different trace", name)
+		}
+		if s.End.Before(s.Start) {
+			t.Errorf("%s ends before it starts", name)
+		}
+	}
+	if len(spans) != len(parents)+1 {
+		t.Errorf("unexpected number of spans: %d", len(spans))
+	}
+
+	if got := spans["echo"].Attributes[trace.AttributeCommand]; got != "echo hello" {
+		t.Errorf("unexpected command: %v", got)
+	}
+	if got := spans["echo"].Attributes[trace.AttributeExitCode]; got != 0 {
+		t.Errorf("unexpected exit code: %v", got)
+	}
+	if got := spans["fail"].Attributes[trace.AttributeExitCode]; got != 3 {
+		t.Errorf("unexpected exit code: %v", got)
+	}
+	if spans["fail"].Err == nil {
+		t.Errorf("fail span should carry the error")
+	}
+	if got := spans["measure"].Attributes["files"]; got != 3 {
+		t.Errorf("unexpected attribute set by the task: %v", got)
+	}
+	if got := spans["fail"].Attributes[trace.AttributeTaskPath]; got != "build/test/fail" {
+		t.Errorf("unexpected path: %v", got)
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "trace.json")
+	tr := trace.NewTracer(&trace.FileExporter{Path: path})
+
+	g := rosie.Group("build")
+	g.Beginning().Then(rosie.Fn("fail", func(context.Context, io.Writer, rosie.Resulter) (interface{}, error) {
+		return nil, errors.New("failure")
+	}))
+	for i := 0; i < 2; i++ {
+		_ = runner.New(tr).Run(context.Background(), g.Clone())
+		if tr.Err() != nil {
+			t.Fatal(tr.Err())
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var (
+		dec    = json.NewDecoder(f)
+		traces int
+	)
+	for dec.More() {
+		var doc struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []struct {
+						TraceID           string `json:"traceId"`
+						StartTimeUnixNano string `json:"startTimeUnixNano"`
+						Name              string `json:"name"`
+						Status            struct {
+							Code    int    `json:"code"`
+							Message string `json:"message"`
+						} `json:"status"`
+					} `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		if err := dec.Decode(&doc); err != nil {
+			t.Fatal(err)
+		}
+		traces++
+
+		spans := doc.ResourceSpans[0].ScopeSpans[0].Spans
+		if len(spans) != 2 {
+			t.Fatalf("unexpected number of spans: %d", len(spans))
+		}
+		for _, s := range spans {
+			if len(s.TraceID) != 32 || s.StartTimeUnixNano == "" {
+				t.Errorf("malformed span: %+v", s)
+			}
+			if s.Name == "fail" && (s.Status.Code != 2 || s.Status.Message != "failure") {
+				t.Errorf("unexpected status: %+v", s.Status)
+			}
+		}
+	}
+	if traces != 2 {
+		t.Errorf("expected one line per run, got %d", traces)
+	}
+}
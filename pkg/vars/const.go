@@ -2,5 +2,8 @@ package vars
 
 const (
 	ExtYAML = ".yaml"
+	ExtYML  = ".yml"
 	ExtJSON = ".json"
+	ExtTOML = ".toml"
+	ExtEnv  = ".env"
 )
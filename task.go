@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"sync"
+	"time"
 
 	"github.com/travelaudience/rosie/pkg/dag"
 )
@@ -65,10 +66,23 @@ var (
 	_ Resulter = &task{}
 )
 
+type cloner interface {
+	// clone creates a copy of a task, stores it as the data of the given anchor and returns its base.
+	// Nodes map the original graph onto its copy.
+	clone(anchor *dag.Node, nodes map[*dag.Node]*dag.Node) *task
+}
+
+var (
+	_ cloner = &task{}
+	_ cloner = &FnTask{}
+	_ cloner = &CmdTask{}
+)
+
 type task struct {
 	name, description string
 	anchor            *dag.Node
 	result            Result
+	started, finished time.Time
 
 	lock sync.RWMutex
 }
@@ -86,9 +100,51 @@ func newHiddenTask(name string) *task {
 	return t
 }
 
+// copy returns a copy of the task that is not attached to any graph and has no result.
+func (t *task) copy() *task {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return &task{
+		name:        t.name,
+		description: t.description,
+	}
+}
+
+// clone implements cloner interface.
+// Unlike executable tasks, it preserves the result as it can be a static input.
+func (t *task) clone(anchor *dag.Node, _ map[*dag.Node]*dag.Node) *task {
+	c := t.copy()
+	c.result = t.Result()
+	c.setAnchor(anchor, c)
+
+	return c
+}
+
 func (t *task) setErr(err error) {
 	t.lock.Lock()
 	t.result.err = err
+	t.finish()
+	t.lock.Unlock()
+}
+
+func (t *task) start() {
+	t.lock.Lock()
+	t.started = time.Now()
+	t.finished = time.Time{}
+	t.lock.Unlock()
+}
+
+// finish records the time the task completed at, it needs to be called with the lock held.
+func (t *task) finish() {
+	if t.finished.IsZero() && !t.started.IsZero() {
+		t.finished = time.Now()
+	}
+}
+
+func (t *task) setDescription(desc string) {
+	t.lock.Lock()
+	t.description = desc
 	t.lock.Unlock()
 }
 
@@ -105,6 +161,7 @@ func (t *task) run() error {
 	} else {
 		t.anchor.MarkAsDone()
 	}
+	t.finish()
 	t.lock.Unlock()
 
 	return t.Result().Err()
@@ -125,6 +182,22 @@ func (t *task) Desc() string {
 	return t.description
 }
 
+// Duration returns how long the execution took, or is taking so far.
+// It returns zero if the task was not executed.
+func (t *task) Duration() time.Duration {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	switch {
+	case t.started.IsZero():
+		return 0
+	case t.finished.IsZero():
+		return time.Since(t.started)
+	default:
+		return t.finished.Sub(t.started)
+	}
+}
+
 // Then implements Attacher interface.
 func (t *task) Then(next Attacher) Attacher {
 	t.lock.Lock()
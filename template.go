@@ -0,0 +1,126 @@
+package rosie
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// Delimiters of templates understood by Cmd and RenderTemplate, e.g. [[.Result.Value]].
+const (
+	DelimLeft  = "[["
+	DelimRight = "]]"
+)
+
+type paramsKey struct{}
+
+// WithParams returns a copy of the context that carries parameters of the workflow.
+// They are available to templates as .Params, e.g. [[.Params.version]].
+func WithParams(ctx context.Context, params map[string]interface{}) context.Context {
+	return context.WithValue(ctx, paramsKey{}, params)
+}
+
+// ParamsFrom returns parameters of the workflow carried by the context, or nil.
+func ParamsFrom(ctx context.Context) map[string]interface{} {
+	params, _ := ctx.Value(paramsKey{}).(map[string]interface{})
+	return params
+}
+
+// templateData is what templates of Cmd and RenderTemplate are executed with.
+type templateData struct {
+	Result templateResult
+	Params map[string]interface{}
+}
+
+// templateResult is the result of the previous task as seen by templates.
+type templateResult struct {
+	Result
+}
+
+// Value returns the value of the result, nil (e.g. for a task without parents) is returned as an empty string,
+// so it is not rendered as <no value>.
+func (r templateResult) Value() interface{} {
+	if v := r.Result.Value(); v != nil {
+		return v
+	}
+	return ""
+}
+
+// parse parses the template text using the delimiters of Cmd, executing it fails if a key is missing, e.g. in .Params.
+func parse(name, text string) (*template.Template, error) {
+	return template.New(name).
+		Delims(DelimLeft, DelimRight).
+		Option("missingkey=error").
+		Parse(text)
+}
+
+// execute executes the template with the data.
+func execute(tmpl *template.Template, data templateData) (string, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// render parses and executes the template text with the data.
+func render(name, text string, data templateData) (string, error) {
+	tmpl, err := parse(name, text)
+	if err != nil {
+		return "", err
+	}
+	return execute(tmpl, data)
+}
+
+// ExecuteTemplate executes the text using the delimiters of Cmd, with the result as .Result and the workflow parameters as .Params.
+// It lets tasks built on top of FnTask accept templates too.
+func ExecuteTemplate(ctx context.Context, text string, res Resulter) (string, error) {
+	return render("template", text, templateData{
+		Result: templateResult{res.Result()},
+		Params: ParamsFrom(ctx),
+	})
+}
+
+// RenderTemplate executes the text/template read from templatePath and writes the output into outputPath.
+// The template is executed with the result of the previous task as .Result and the workflow parameters as .Params (see WithParams),
+// using the same delimiters as Cmd, e.g. image: [[.Params.image]]:[[.Result.Value]].
+// Missing parent directories are created, the output file has the mode of the template and is replaced atomically.
+// It produces the path of the output file.
+func RenderTemplate(name, templatePath, outputPath string) *FnTask {
+	desc := fmt.Sprintf("render %s -> %s", templatePath, outputPath)
+	return DescribedFn(name, desc, func(ctx context.Context, w io.Writer, res Resulter) (interface{}, error) {
+		fi, err := os.Stat(templatePath)
+		if err != nil {
+			return nil, err
+		}
+		/* #nosec */
+		text, err := ioutil.ReadFile(templatePath)
+		if err != nil {
+			return nil, err
+		}
+
+		out, err := render(filepath.Base(templatePath), string(text), templateData{
+			Result: templateResult{res.Result()},
+			Params: ParamsFrom(ctx),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+			return nil, err
+		}
+		if err := writeFileAtomic(outputPath, []byte(out), fi.Mode().Perm()); err != nil {
+			return nil, err
+		}
+		if _, err := fmt.Fprintf(w, "template %s rendered into %s %dB\n", templatePath, outputPath, len(out)); err != nil {
+			return nil, err
+		}
+		return outputPath, nil
+	})
+}
@@ -0,0 +1,88 @@
+package rosie_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/travelaudience/rosie"
+	"github.com/travelaudience/rosie/pkg/runner/testrunner"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rosie-template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	tmpl := filepath.Join(dir, "deployment.yaml.tmpl")
+	out := filepath.Join(dir, "out", "deployment.yaml")
+	if err := ioutil.WriteFile(tmpl, []byte("image: [[.Params.image]]:[[.Result.Value]]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	g := rosie.Group("render")
+	g.Beginning().
+		Then(rosie.Fn("version", func(_ context.Context, _ io.Writer, _ rosie.Resulter) (interface{}, error) {
+			return "v1.2.3", nil
+		})).
+		Then(rosie.RenderTemplate("deployment", tmpl, out)).
+		Then(rosie.Cmd("cat", "cat", "[[.Result.Value]]"))
+
+	ctx := rosie.WithParams(context.Background(), map[string]interface{}{"image": "rosie"})
+	rec := testrunner.ExecuteContext(ctx, t, g)
+	if rec.Err != nil {
+		t.Fatal(rec.Err)
+	}
+	testrunner.AssertResult(t, rec, "deployment", out)
+	testrunner.AssertResult(t, rec, "cat", []string{"image: rosie:v1.2.3"})
+
+	fi, err := os.Stat(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("unexpected mode: %s", fi.Mode())
+	}
+}
+
+func TestRenderTemplate_missingParam(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rosie-template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	tmpl := filepath.Join(dir, "Dockerfile.tmpl")
+	out := filepath.Join(dir, "Dockerfile")
+	if err := ioutil.WriteFile(tmpl, []byte("FROM [[.Params.base]]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	g := rosie.Group("render")
+	g.Beginning().Then(rosie.RenderTemplate("dockerfile", tmpl, out))
+
+	ctx := rosie.WithParams(context.Background(), map[string]interface{}{})
+	rec := testrunner.ExecuteContext(ctx, t, g)
+	if rec.Err == nil {
+		t.Fatal("error expected")
+	}
+	testrunner.AssertFailed(t, rec, "dockerfile")
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("output should not be written: %v", err)
+	}
+}
+
+func TestCmd_Render_nilResult(t *testing.T) {
+	got, err := rosie.Cmd("echo", "echo", "<no value>", "[[.Result.Value]]").Render(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "echo <no value> " {
+		t.Errorf("unexpected command: %q", got)
+	}
+}